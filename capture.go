@@ -0,0 +1,340 @@
+package egl
+
+/*
+#cgo pkg-config: egl x11 xcomposite xdamage xfixes
+
+#include <EGL/egl.h>
+#include <X11/Xlib.h>
+#include <X11/extensions/Xcomposite.h>
+#include <X11/extensions/Xdamage.h>
+
+// damageEvent is a flattened XDamageNotifyEvent, since cgo can't look
+// inside the XEvent union.
+typedef struct {
+	int x, y, width, height;
+	int drawableWidth, drawableHeight;
+} damageEvent;
+
+typedef struct {
+	int eventType;
+	Damage damage;
+} damageMatch;
+
+static Bool isDamageEvent(Display *display, XEvent *event, XPointer arg) {
+	damageMatch *match = (damageMatch *)arg;
+	return event->type == match->eventType &&
+		((XDamageNotifyEvent *)event)->damage == match->damage;
+}
+
+// Only this capture's damage events are taken, so several captures can
+// share a connection.
+static int nextDamageEvent(Display *display, int eventType, Damage damage, damageEvent *out) {
+	damageMatch match = {eventType, damage};
+	XEvent event;
+	if (!XCheckIfEvent(display, &event, isDamageEvent, (XPointer)&match)) {
+		return 0;
+	}
+	XDamageNotifyEvent *notify = (XDamageNotifyEvent *)&event;
+	out->x = notify->area.x;
+	out->y = notify->area.y;
+	out->width = notify->area.width;
+	out->height = notify->area.height;
+	out->drawableWidth = notify->geometry.width;
+	out->drawableHeight = notify->geometry.height;
+	return 1;
+}
+
+// When the capture selected StructureNotify itself, nobody else reads the
+// window's other structure events, so they're discarded rather than left
+// to pile up in the queue.
+static int windowDestroyed(Display *display, Window window, int drain) {
+	XEvent event;
+	if (!drain) {
+		return XCheckTypedWindowEvent(display, window, DestroyNotify, &event);
+	}
+	int destroyed = 0;
+	while (XCheckWindowEvent(display, window, StructureNotifyMask, &event)) {
+		if (event.type == DestroyNotify && event.xdestroywindow.window == window) {
+			destroyed = 1;
+		}
+	}
+	return destroyed;
+}
+
+static int ignoreXError(Display *display, XErrorEvent *event) {
+	return 0;
+}
+
+// The window may already be gone without the capture having seen its
+// DestroyNotify, so errors from the dead XIDs are ignored. Earlier requests
+// are synced first so their errors still reach the usual handler.
+static void releaseCapture(Display *display, Window window, Damage damage, long eventMask) {
+	XSync(display, False);
+	XErrorHandler oldHandler = XSetErrorHandler(ignoreXError);
+	XDamageDestroy(display, damage);
+	XCompositeUnredirectWindow(display, window, CompositeRedirectAutomatic);
+	XSelectInput(display, window, eventMask);
+	XSync(display, False);
+	XSetErrorHandler(oldHandler);
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"image"
+	"sync"
+	"time"
+)
+
+// How often the damage goroutine polls the X connection when idle.
+const capturePollInterval = 5 * time.Millisecond
+
+/*
+ * WindowCapture follows another application's X window through
+ * XComposite. The window is redirected off screen, with the server still
+ * showing it, and its contents are read through the pixmap XComposite
+ * names for it. Areas the application redraws are sent on Damage, in
+ * window coordinates with a top-left origin; when the receiver falls
+ * behind, pending areas are merged rather than dropped. Damage is closed
+ * when the window is destroyed or Destroy is called.
+ *
+ * When another reader on the same connection also selects the window's
+ * structure events, such as the goroutine of a Window from CreateWindow,
+ * whichever polls first takes the DestroyNotify. If it isn't the capture,
+ * Damage stays open until Destroy.
+ */
+type WindowCapture struct {
+	Display *Display
+	Damage <-chan image.Rectangle
+
+	config Config
+	xWindow C.Window
+	damage C.Damage
+	damageEventType C.int
+	eventMask C.long // the client's event mask before capturing
+	ownsStructureEvents bool // StructureNotify was added by the capture
+
+	lock sync.Mutex // guards the fields below, shared with the goroutine
+	xPixmap C.Pixmap
+	surface *Surface
+	width, height int
+	stale bool // window was resized since xPixmap was named
+	gone bool // window was destroyed
+
+	quit chan struct{}
+	done chan struct{}
+	destroyOnce sync.Once
+}
+
+/*
+ * CaptureWindow starts capturing window. config must be usable with pixmaps
+ * as deep as the window, typically one whose NativeVisualId matches the
+ * window's visual. The window must be mapped whenever Surface, Frame or
+ * Image is called. Requires the Composite and DAMAGE extensions.
+ */
+func (display *Display) CaptureWindow(window NativeWindow, config Config) (*WindowCapture, error) {
+	xDisplay := display.xDisplay
+	if xDisplay == nil {
+		return nil, errors.New("window capture requires a display opened on an X server")
+	}
+
+	var eventBase, errorBase C.int
+	if C.XCompositeQueryExtension(xDisplay, &eventBase, &errorBase) == C.False {
+		return nil, errors.New("X server does not support the Composite extension")
+	}
+	if C.XDamageQueryExtension(xDisplay, &eventBase, &errorBase) == C.False {
+		return nil, errors.New("X server does not support the DAMAGE extension")
+	}
+
+	xWindow := C.Window(window)
+	var attributes C.XWindowAttributes
+	if C.XGetWindowAttributes(xDisplay, xWindow, &attributes) == 0 {
+		return nil, errors.New("XGetWindowAttributes failed, window may not exist")
+	}
+	// add to this client's event mask for the window, which CreateWindow
+	// may have set
+	eventMask := attributes.your_event_mask
+	C.XSelectInput(xDisplay, xWindow, eventMask | C.StructureNotifyMask)
+
+	C.XCompositeRedirectWindow(xDisplay, xWindow, C.CompositeRedirectAutomatic)
+	damage := C.XDamageCreate(xDisplay, C.Drawable(xWindow), C.XDamageReportDeltaRectangles)
+	C.XSync(xDisplay, C.False)
+
+	damageChannel := make(chan image.Rectangle, 64)
+	capture := new(WindowCapture)
+	capture.Display = display
+	capture.Damage = damageChannel
+	capture.config = config
+	capture.xWindow = xWindow
+	capture.damage = damage
+	capture.damageEventType = eventBase + C.XDamageNotify
+	capture.eventMask = eventMask
+	capture.ownsStructureEvents = eventMask & C.StructureNotifyMask == 0
+	capture.width = int(attributes.width)
+	capture.height = int(attributes.height)
+	capture.stale = true
+	capture.quit = make(chan struct{})
+	capture.done = make(chan struct{})
+
+	go capture.pumpDamage(damageChannel)
+	return capture, nil
+}
+
+func (capture *WindowCapture) pumpDamage(damageChannel chan<- image.Rectangle) {
+	defer close(capture.done)
+	defer close(damageChannel)
+
+	xDisplay := capture.Display.xDisplay
+	drain := C.int(0)
+	if capture.ownsStructureEvents {
+		drain = 1
+	}
+	ticker := time.NewTicker(capturePollInterval)
+	defer ticker.Stop()
+
+	var pending image.Rectangle
+	for {
+		var event C.damageEvent
+		damaged := false
+		for C.nextDamageEvent(xDisplay, capture.damageEventType, capture.damage, &event) != 0 {
+			damaged = true
+			area := image.Rect(int(event.x), int(event.y), int(event.x + event.width), int(event.y + event.height))
+			pending = pending.Union(area)
+
+			capture.lock.Lock()
+			width, height := int(event.drawableWidth), int(event.drawableHeight)
+			if width != capture.width || height != capture.height {
+				capture.width, capture.height = width, height
+				capture.stale = true
+			}
+			capture.lock.Unlock()
+		}
+		if damaged {
+			// re-arm, so areas damaged again are reported again
+			C.XDamageSubtract(xDisplay, capture.damage, 0, 0)
+			C.XFlush(xDisplay)
+		}
+
+		if C.windowDestroyed(xDisplay, capture.xWindow, drain) != 0 {
+			capture.lock.Lock()
+			capture.gone = true
+			capture.lock.Unlock()
+			return
+		}
+
+		if !pending.Empty() {
+			select {
+				case damageChannel <- pending:
+					pending = image.Rectangle{}
+				default:
+			}
+		}
+
+		select {
+			case <-ticker.C:
+			case <-capture.quit:
+				return
+		}
+	}
+}
+
+/*
+ * Surface returns a pixmap surface showing the window's current contents.
+ * After the window is resized a new pixmap is named and a new surface
+ * created, so callers shouldn't hold on to the result across calls.
+ */
+func (capture *WindowCapture) Surface() (*Surface, error) {
+	capture.lock.Lock()
+	defer capture.lock.Unlock()
+	if capture.gone {
+		return nil, errors.New("captured window was destroyed")
+	}
+	if !capture.stale && capture.surface != nil {
+		return capture.surface, nil
+	}
+
+	xDisplay := capture.Display.xDisplay
+	pixmap := C.XCompositeNameWindowPixmap(xDisplay, capture.xWindow)
+	surface, surfaceErr := capture.Display.CreatePixmapSurfaceFromPixmap(capture.config, nil, NativePixmap(pixmap), NoTexture)
+	if surfaceErr != nil {
+		C.XFreePixmap(xDisplay, pixmap)
+		return nil, surfaceErr
+	}
+
+	releaseErr := capture.releasePixmap()
+	capture.xPixmap = pixmap
+	capture.surface = surface
+	capture.stale = false
+	return surface, releaseErr
+}
+
+/*
+ * Frame reads the window's current contents into a Go image through the
+ * surface's CopyBuffers.
+ */
+func (capture *WindowCapture) Frame() (image.Image, error) {
+	surface, surfaceErr := capture.Surface()
+	if surfaceErr != nil {
+		return nil, surfaceErr
+	}
+	return surface.CopyBuffers()
+}
+
+/*
+ * Image makes an EGLImage of the window's current pixmap, to be bound as a
+ * texture. The caller must Release it, and should do so before the next
+ * resize.
+ */
+func (capture *WindowCapture) Image() (*Image, error) {
+	surface, surfaceErr := capture.Surface()
+	if surfaceErr != nil {
+		return nil, surfaceErr
+	}
+	return capture.Display.CreateImageFromPixmap(surface.Pixmap())
+}
+
+// releasePixmap destroys the current surface and frees its pixmap. The lock
+// must be held.
+func (capture *WindowCapture) releasePixmap() error {
+	var result error
+	if capture.surface != nil {
+		result = capture.surface.Destroy()
+		capture.surface = nil
+	}
+	if capture.xPixmap != 0 {
+		C.XFreePixmap(capture.Display.xDisplay, capture.xPixmap)
+		capture.xPixmap = 0
+	}
+	return result
+}
+
+/*
+ * Destroy stops capturing, closes Damage, and un-redirects the window and
+ * restores this client's event mask for it if it still exists. Only the
+ * first call releases anything, later and concurrent calls return an error.
+ */
+func (capture *WindowCapture) Destroy() error {
+	destroyErr := errors.New("capture already destroyed")
+	capture.destroyOnce.Do(func() {
+		destroyErr = capture.destroy()
+	})
+	return destroyErr
+}
+
+func (capture *WindowCapture) destroy() error {
+	close(capture.quit)
+	<-capture.done
+
+	capture.lock.Lock()
+	defer capture.lock.Unlock()
+	result := capture.releasePixmap()
+
+	// the server frees both when the window is destroyed
+	if !capture.gone {
+		xErrorHandlerLock.Lock()
+		C.releaseCapture(capture.Display.xDisplay, capture.xWindow, capture.damage, capture.eventMask)
+		xErrorHandlerLock.Unlock()
+	}
+	return result
+}
@@ -0,0 +1,176 @@
+package egl
+
+import (
+	"fmt"
+	"image"
+	"io"
+)
+
+// The extension each GLColorspace value needs, besides EGL 1.5 for sRGB and
+// linear.
+var colorspaceExtensions = map[Attrib]string{
+	GLColorspaceSRGB: "EGL_KHR_gl_colorspace",
+	GLColorspaceLinear: "EGL_KHR_gl_colorspace",
+	GLColorspaceBT2020Linear: "EGL_EXT_gl_colorspace_bt2020_linear",
+	GLColorspaceBT2020PQ: "EGL_EXT_gl_colorspace_bt2020_pq",
+	GLColorspaceDisplayP3: "EGL_EXT_gl_colorspace_display_p3",
+	GLColorspaceDisplayP3Linear: "EGL_EXT_gl_colorspace_display_p3_linear",
+	GLColorspaceSCRGB: "EGL_EXT_gl_colorspace_scrgb",
+	GLColorspaceSCRGBLinear: "EGL_EXT_gl_colorspace_scrgb_linear",
+}
+
+var allColorspaces = []Attrib{
+	GLColorspaceSRGB,
+	GLColorspaceLinear,
+	GLColorspaceBT2020Linear,
+	GLColorspaceBT2020PQ,
+	GLColorspaceDisplayP3,
+	GLColorspaceDisplayP3Linear,
+	GLColorspaceSCRGB,
+	GLColorspaceSCRGBLinear,
+}
+
+func colorspaceName(colorspace Attrib) string {
+	switch colorspace {
+		case GLColorspaceSRGB:
+			return "sRGB"
+		case GLColorspaceLinear:
+			return "linear"
+		case GLColorspaceBT2020Linear:
+			return "BT.2020 linear"
+		case GLColorspaceBT2020PQ:
+			return "BT.2020 PQ"
+		case GLColorspaceDisplayP3:
+			return "Display P3"
+		case GLColorspaceDisplayP3Linear:
+			return "Display P3 linear"
+		case GLColorspaceSCRGB:
+			return "scRGB"
+		case GLColorspaceSCRGBLinear:
+			return "scRGB linear"
+	}
+	return fmt.Sprintf("colorspace 0x%X", int(colorspace))
+}
+
+// SupportsColorspace reports whether surfaces can be created with
+// colorspace as their GLColorspace attribute.
+func (display *Display) SupportsColorspace(colorspace Attrib) bool {
+	extension, known := colorspaceExtensions[colorspace]
+	if !known {
+		return false
+	}
+	if colorspace == GLColorspaceSRGB || colorspace == GLColorspaceLinear {
+		major, minor := display.GetVersion()
+		if major > 1 || major == 1 && minor >= 5 {
+			return true
+		}
+	}
+	return display.HasExtension(extension)
+}
+
+func (display *Display) SupportedColorspaces() []Attrib {
+	var supported []Attrib
+	for _, colorspace := range(allColorspaces) {
+		if display.SupportsColorspace(colorspace) {
+			supported = append(supported, colorspace)
+		}
+	}
+	return supported
+}
+
+/*
+ * checkColorspace returns an error naming the missing extension if
+ * attribList asks for a colorspace the display doesn't support.
+ */
+func (display *Display) checkColorspace(attribList []Attrib) error {
+	for i := 0; i + 1 < len(attribList); i += 2 {
+		if attribList[i] == None {
+			break
+		}
+		if attribList[i] != GLColorspace {
+			continue
+		}
+
+		colorspace := attribList[i + 1]
+		if display.SupportsColorspace(colorspace) {
+			return nil
+		}
+		extension, known := colorspaceExtensions[colorspace]
+		if !known {
+			return fmt.Errorf("unknown GLColorspace value 0x%X", int(colorspace))
+		}
+		return fmt.Errorf("%s colorspace requires %s", colorspaceName(colorspace), extension)
+	}
+	return nil
+}
+
+// requestsColorspace reports whether attribList sets GLColorspace.
+func requestsColorspace(attribList []Attrib) bool {
+	for i := 0; i + 1 < len(attribList); i += 2 {
+		if attribList[i] == None {
+			break
+		}
+		if attribList[i] == GLColorspace {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+ * Colorspace returns the surface's GLColorspace. Surfaces created without
+ * one, or on displays without colorspace support, are linear.
+ */
+func (surface *Surface) Colorspace() (Attrib, error) {
+	display := surface.Display
+	if !display.SupportsColorspace(GLColorspaceLinear) {
+		return GLColorspaceLinear, nil
+	}
+	return surface.Query(GLColorspace)
+}
+
+/*
+ * pixelColorspace returns the colorspace read back pixels should be tagged
+ * with. EGL calls surfaces linear by default, but fixed point framebuffers
+ * hold display-referred values that viewers must treat as sRGB, so linear
+ * is only kept for float surfaces created with an explicit GLColorspace.
+ */
+func (surface *Surface) pixelColorspace() (Attrib, error) {
+	colorspace, colorspaceErr := surface.Colorspace()
+	if colorspaceErr != nil {
+		return 0, colorspaceErr
+	}
+	if colorspace == GLColorspaceLinear && !(surface.colorspaceRequested && surface.floatComponents()) {
+		return GLColorspaceSRGB, nil
+	}
+	return colorspace, nil
+}
+
+/*
+ * TaggedImage is a read back image along with the colorspace its pixels
+ * are in, which for fixed point surfaces without an explicit GLColorspace
+ * is sRGB.
+ */
+type TaggedImage struct {
+	image.Image
+	Colorspace Attrib
+}
+
+// CopyBuffersTagged is CopyBuffers, also reporting the pixels' colorspace.
+func (surface *Surface) CopyBuffersTagged() (*TaggedImage, error) {
+	colorspace, colorspaceErr := surface.pixelColorspace()
+	if colorspaceErr != nil {
+		return nil, colorspaceErr
+	}
+
+	goImage, copyErr := surface.CopyBuffers()
+	if copyErr != nil {
+		return nil, copyErr
+	}
+	return &TaggedImage{goImage, colorspace}, nil
+}
+
+// EncodePNG writes the image as a PNG tagged with its colorspace.
+func (tagged *TaggedImage) EncodePNG(w io.Writer) error {
+	return EncodePNG(w, tagged.Image, tagged.Colorspace)
+}
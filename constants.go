@@ -92,6 +92,11 @@ var AllConfigAttribNames [34]Attrib = [34]Attrib{
 	Conformant,
 }
 
+// EGL_EXT_pixel_format_float config attribute
+const (
+	ColorComponentType = C.EGL_COLOR_COMPONENT_TYPE_EXT
+)
+
 // Config attribute values
 const (
 	SlowConfig = C.EGL_SLOW_CONFIG
@@ -99,6 +104,8 @@ const (
 	TransparentRGB = C.EGL_TRANSPARENT_RGB
 	RGBBuffer = C.EGL_RGB_BUFFER
 	LuminanceBuffer = C.EGL_LUMINANCE_BUFFER
+	ColorComponentTypeFixed = C.EGL_COLOR_COMPONENT_TYPE_FIXED_EXT
+	ColorComponentTypeFloat = C.EGL_COLOR_COMPONENT_TYPE_FLOAT_EXT
 )
 
 // Config attribute mask bits
@@ -143,6 +150,75 @@ const (
 	MultisampleResolve = C.EGL_MULTISAMPLE_RESOLVE
 )
 
+// EGL_NOK_texture_from_pixmap config attribute
+const (
+	YInverted = C.EGL_Y_INVERTED_NOK
+)
+
+// TextureFormat and TextureTarget values
+const (
+	NoTexture = C.EGL_NO_TEXTURE
+	TextureRGB = C.EGL_TEXTURE_RGB
+	TextureRGBA = C.EGL_TEXTURE_RGBA
+	Texture2D = C.EGL_TEXTURE_2D
+)
+
+// VgAlphaFormat values
+const (
+	VgAlphaFormatNonpre = C.EGL_VG_ALPHA_FORMAT_NONPRE
+	VgAlphaFormatPre = C.EGL_VG_ALPHA_FORMAT_PRE
+)
+
+// SwapBehavior values
+const (
+	BufferPreserved = C.EGL_BUFFER_PRESERVED
+	BufferDestroyed = C.EGL_BUFFER_DESTROYED
+)
+
+// MultisampleResolve values
+const (
+	MultisampleResolveDefault = C.EGL_MULTISAMPLE_RESOLVE_DEFAULT
+	MultisampleResolveBox = C.EGL_MULTISAMPLE_RESOLVE_BOX
+)
+
+// GetCurrentSurface targets
+const (
+	Draw = C.EGL_DRAW
+	Read = C.EGL_READ
+)
+
+// EGL_EXT_buffer_age / EGL_KHR_partial_update surface attribute
+const (
+	BufferAge = C.EGL_BUFFER_AGE_EXT
+)
+
+// CreatePbufferSurface / CreatePixmapSurface / CreateWindowSurface attribute
+// and its values (EGL 1.5, EGL_KHR_gl_colorspace and EXT variants)
+const (
+	GLColorspace = C.EGL_GL_COLORSPACE
+	GLColorspaceSRGB = C.EGL_GL_COLORSPACE_SRGB
+	GLColorspaceLinear = C.EGL_GL_COLORSPACE_LINEAR
+	GLColorspaceBT2020Linear = C.EGL_GL_COLORSPACE_BT2020_LINEAR_EXT
+	GLColorspaceBT2020PQ = C.EGL_GL_COLORSPACE_BT2020_PQ_EXT
+	GLColorspaceDisplayP3 = C.EGL_GL_COLORSPACE_DISPLAY_P3_EXT
+	GLColorspaceDisplayP3Linear = C.EGL_GL_COLORSPACE_DISPLAY_P3_LINEAR_EXT
+	GLColorspaceSCRGB = C.EGL_GL_COLORSPACE_SCRGB_EXT
+	GLColorspaceSCRGBLinear = C.EGL_GL_COLORSPACE_SCRGB_LINEAR_EXT
+)
+
+// CreateSync types, sync attributes and their values (EGL 1.5,
+// EGL_KHR_fence_sync and EGL_KHR_reusable_sync)
+const (
+	SyncFence = C.EGL_SYNC_FENCE
+	SyncReusable = C.EGL_SYNC_REUSABLE_KHR
+	SyncType = C.EGL_SYNC_TYPE
+	SyncStatus = C.EGL_SYNC_STATUS
+	SyncCondition = C.EGL_SYNC_CONDITION
+	Signaled = C.EGL_SIGNALED
+	Unsignaled = C.EGL_UNSIGNALED
+	SyncPriorCommandsComplete = C.EGL_SYNC_PRIOR_COMMANDS_COMPLETE
+)
+
 // RenderBuffer values / BindTexImage / ReleaseTexImage buffer targets
 const (
 	BackBuffer = C.EGL_BACK_BUFFER
@@ -201,6 +277,8 @@ func (name Attrib) String() string {
 			return "True if bindable to RGBA textures"
 		case ColorBufferType:
 			return "color buffer type"
+		case ColorComponentType:
+			return "fixed or floating point color components"
 		case ConfigCaveat:
 			return "any caveats for the configuration"
 		case ConfigId:
@@ -277,6 +355,10 @@ func (name Attrib) String() string {
 			return "Vertical dot pitch"
 		case Width:
 			return "Width of surface"
+		case BufferAge:
+			return "Age of the back buffer in frames"
+		case GLColorspace:
+			return "Color space of the client API framebuffer"
 	}
 	return fmt.Sprintf("EGL attribute name %d", name)
 }
@@ -9,11 +9,19 @@ const EGLContext kNoContext = EGL_NO_CONTEXT;
 */
 import "C"
 
+import (
+	"sync"
+)
+
 var noContext C.EGLContext = C.kNoContext
 
 type Context struct {
 	eglContext C.EGLContext
 	Display *Display
+	group *ShareGroup // set before the context is returned, never changed
+	api int // client API, for share group members
+	glLock sync.Mutex
+	gl *GL
 }
 
 func destroyContext(context *Context) {
@@ -26,6 +34,9 @@ func (context *Context) Destroy() error {
 	if success == C.EGL_FALSE {
 		return getError()
 	}
+	if context.group != nil {
+		context.group.remove(context)
+	}
 	return nil
 }
 
@@ -0,0 +1,136 @@
+package egl
+
+/*
+#cgo pkg-config: egl
+
+#include <EGL/egl.h>
+
+typedef EGLBoolean (*damageFunc)(EGLDisplay display, EGLSurface surface, EGLint *rects, EGLint count);
+
+// cgo can't call function pointers directly.
+static EGLBoolean callDamageFunc(void *function, EGLDisplay display, EGLSurface surface, EGLint *rects, EGLint count) {
+	return ((damageFunc)function)(display, surface, rects, count);
+}
+*/
+import "C"
+
+import (
+	"image"
+	"time"
+	"unsafe"
+)
+
+/*
+ * eglRects converts rectangles with a top-left origin to the flat x, y,
+ * width, height list EGL expects, which has a bottom-left origin. Rectangles
+ * are clipped to the surface and empty ones are dropped.
+ */
+func (surface *Surface) eglRects(rects []image.Rectangle) ([]C.EGLint, error) {
+	width, widthErr := surface.Query(Width)
+	if widthErr != nil {
+		return nil, widthErr
+	}
+	height, heightErr := surface.Query(Height)
+	if heightErr != nil {
+		return nil, heightErr
+	}
+
+	bounds := image.Rect(0, 0, int(width), int(height))
+	eglRects := make([]C.EGLint, 0, len(rects) * 4)
+	for _, rect := range(rects) {
+		rect = rect.Intersect(bounds)
+		if rect.Empty() {
+			continue
+		}
+		eglRects = append(eglRects,
+			C.EGLint(rect.Min.X),
+			C.EGLint(int(height) - rect.Max.Y),
+			C.EGLint(rect.Dx()),
+			C.EGLint(rect.Dy()))
+	}
+	return eglRects, nil
+}
+
+func (surface *Surface) callDamageFunc(function unsafe.Pointer, rects []image.Rectangle) error {
+	eglRects, rectsErr := surface.eglRects(rects)
+	if rectsErr != nil {
+		return rectsErr
+	}
+
+	var rectPointer *C.EGLint
+	if len(eglRects) > 0 {
+		rectPointer = &eglRects[0]
+	}
+	success := C.callDamageFunc(
+		function,
+		surface.Display.eglDisplay,
+		surface.eglSurface,
+		rectPointer,
+		C.EGLint(len(eglRects) / 4))
+	if success == C.EGL_FALSE {
+		return getError()
+	}
+	return nil
+}
+
+/*
+ * SwapBuffersWithDamage presents the surface, telling the compositor only
+ * the given rectangles changed. Rectangles use Go's top-left origin. Without
+ * EGL_KHR_swap_buffers_with_damage or EGL_EXT_swap_buffers_with_damage, or
+ * when rects is empty, the whole surface is swapped.
+ */
+func (surface *Surface) SwapBuffersWithDamage(rects []image.Rectangle) error {
+	display := surface.Display
+
+	var function unsafe.Pointer
+	if display.HasExtension("EGL_KHR_swap_buffers_with_damage") {
+		function = procAddress("eglSwapBuffersWithDamageKHR")
+	} else if display.HasExtension("EGL_EXT_swap_buffers_with_damage") {
+		function = procAddress("eglSwapBuffersWithDamageEXT")
+	}
+	if function == nil || len(rects) == 0 {
+		return surface.SwapBuffers()
+	}
+
+	if surface.timer != nil {
+		defer surface.timer.record(time.Now(), surface.swapInterval)
+	}
+	return surface.callDamageFunc(function, rects)
+}
+
+/*
+ * BufferAge returns how many frames old the contents of the back buffer
+ * are, or 0 if they are undefined and the whole frame must be redrawn. It
+ * also returns 0 when neither EGL_EXT_buffer_age nor EGL_KHR_partial_update
+ * is available. The surface must be current.
+ */
+func (surface *Surface) BufferAge() (int, error) {
+	display := surface.Display
+	if !display.HasExtension("EGL_EXT_buffer_age") && !display.HasExtension("EGL_KHR_partial_update") {
+		return 0, nil
+	}
+
+	age, queryErr := surface.Query(BufferAge)
+	if queryErr != nil {
+		return 0, queryErr
+	}
+	return int(age), nil
+}
+
+/*
+ * SetDamageRegion limits the pixels the client API may update in this
+ * frame to rects, using EGL_KHR_partial_update. It must be called after
+ * BufferAge and before drawing. Without the extension it does nothing, and
+ * the whole surface stays writable.
+ */
+func (surface *Surface) SetDamageRegion(rects []image.Rectangle) error {
+	if !surface.Display.HasExtension("EGL_KHR_partial_update") {
+		return nil
+	}
+
+	function := procAddress("eglSetDamageRegionKHR")
+	if function == nil {
+		return nil
+	}
+	return surface.callDamageFunc(function, rects)
+}
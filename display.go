@@ -4,11 +4,18 @@ package egl
 #cgo pkg-config: egl
 
 #include <EGL/egl.h>
+#include <EGL/eglext.h>
 
 // These variables are necessary because EGL_DEFAULT_DISPLAY and EGL_NO_DISPLAY
 // are pointer constants, and cgo doesn't translate them correctly.
 const EGLNativeDisplayType kDefaultDisplay = EGL_DEFAULT_DISPLAY;
 const EGLDisplay kNoDisplay = EGL_NO_DISPLAY;
+
+typedef EGLDisplay (*getPlatformDisplayFunc)(EGLenum platform, void *nativeDisplay, const EGLint *attribList);
+
+static EGLDisplay callGetPlatformDisplay(void *function, EGLenum platform) {
+	return ((getPlatformDisplayFunc)function)(platform, NULL, NULL);
+}
 */
 import "C"
 
@@ -17,6 +24,7 @@ import (
 	"errors"
 	"fmt"
 	//"runtime"
+	"strings"
 )
 
 var defaultDisplay C.EGLNativeDisplayType = C.kDefaultDisplay
@@ -29,6 +37,44 @@ func OpenDisplay() (*Display, error) {
 	return display, nil
 }
 
+/*
+ * OpenSurfacelessDisplay opens Mesa's surfaceless platform, which renders
+ * without any window system. It supports pbuffers and contexts without
+ * surfaces, read back with Surface.ReadPixels.
+ */
+func OpenSurfacelessDisplay() (*Display, error) {
+	clientExtensions := C.eglQueryString(noDisplay, C.EGL_EXTENSIONS)
+	if clientExtensions == nil {
+		return nil, errors.New("EGL client extensions are not supported")
+	}
+	extensions := strings.Fields(C.GoString(clientExtensions))
+	var platformBase, surfaceless bool
+	for _, extension := range(extensions) {
+		switch extension {
+			case "EGL_EXT_platform_base":
+				platformBase = true
+			case "EGL_MESA_platform_surfaceless":
+				surfaceless = true
+		}
+	}
+	if !platformBase || !surfaceless {
+		return nil, errors.New("EGL_MESA_platform_surfaceless is not supported")
+	}
+
+	function := procAddress("eglGetPlatformDisplayEXT")
+	if function == nil {
+		return nil, errors.New("eglGetPlatformDisplayEXT could not be resolved")
+	}
+	eglDisplay := C.callGetPlatformDisplay(function, C.EGL_PLATFORM_SURFACELESS_MESA)
+	if eglDisplay == noDisplay {
+		return nil, getError()
+	}
+
+	display := new(Display)
+	display.eglDisplay = eglDisplay
+	return display, nil
+}
+
 func (display *Display) Initialize() error {
 	if display.eglDisplay == noDisplay {
 		return getError()
@@ -179,6 +225,11 @@ func (display *Display) PrintConfig(config Config) {
 }
 
 func (display *Display) CreatePbufferSurface(config Config, attribList []Attrib) (*Surface, error) {
+	colorspaceErr := display.checkColorspace(attribList)
+	if colorspaceErr != nil {
+		return nil, colorspaceErr
+	}
+
 	var eglAttribs *C.EGLint
 	if attribList != nil {
 		eglAttribs = (*C.EGLint)(&(attribList[0]))
@@ -193,12 +244,17 @@ func (display *Display) CreatePbufferSurface(config Config, attribList []Attrib)
 	//runtime.SetFinalizer(surface, destroySurface)
 	surface.Display = display
 	surface.eglSurface = eglSurface
+	surface.config = config
+	surface.colorspaceRequested = requestsColorspace(attribList)
 	return surface, nil
 }
 
 func (display *Display) CreateContext(config Config, shareContext *Context, attribList []Attrib) (*Context, error) {
 	var eglShareContext C.EGLContext
 	if shareContext != nil {
+		if shareContext.Display.eglDisplay != display.eglDisplay {
+			return nil, errors.New("share context belongs to a different display, contexts can only share objects within one display")
+		}
 		eglShareContext = shareContext.eglContext
 	}
 
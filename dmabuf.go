@@ -0,0 +1,415 @@
+package egl
+
+/*
+#cgo pkg-config: egl
+
+#include <EGL/egl.h>
+#include <EGL/eglext.h>
+
+typedef EGLBoolean (*queryDmaBufFormatsFunc)(EGLDisplay display, EGLint maxFormats, EGLint *formats, EGLint *formatCount);
+typedef EGLBoolean (*queryDmaBufModifiersFunc)(EGLDisplay display, EGLint format, EGLint maxModifiers, EGLuint64KHR *modifiers, EGLBoolean *externalOnly, EGLint *modifierCount);
+typedef EGLBoolean (*exportQueryFunc)(EGLDisplay display, EGLImage image, int *fourcc, int *planeCount, EGLuint64KHR *modifiers);
+typedef EGLBoolean (*exportFunc)(EGLDisplay display, EGLImage image, int *fds, EGLint *strides, EGLint *offsets);
+
+static EGLBoolean callQueryDmaBufFormats(void *function, EGLDisplay display, EGLint maxFormats, EGLint *formats, EGLint *formatCount) {
+	return ((queryDmaBufFormatsFunc)function)(display, maxFormats, formats, formatCount);
+}
+
+static EGLBoolean callQueryDmaBufModifiers(void *function, EGLDisplay display, EGLint format, EGLint maxModifiers, EGLuint64KHR *modifiers, EGLBoolean *externalOnly, EGLint *modifierCount) {
+	return ((queryDmaBufModifiersFunc)function)(display, format, maxModifiers, modifiers, externalOnly, modifierCount);
+}
+
+static EGLBoolean callExportQuery(void *function, EGLDisplay display, EGLImage image, int *fourcc, int *planeCount, EGLuint64KHR *modifiers) {
+	return ((exportQueryFunc)function)(display, image, fourcc, planeCount, modifiers);
+}
+
+static EGLBoolean callExport(void *function, EGLDisplay display, EGLImage image, int *fds, EGLint *strides, EGLint *offsets) {
+	return ((exportFunc)function)(display, image, fds, strides, offsets);
+}
+*/
+import "C"
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"unsafe"
+)
+
+// DRM format modifiers with special meanings
+const (
+	DrmFormatModLinear = 0
+	DrmFormatModInvalid = 0x00FFFFFFFFFFFFFF // no explicit modifier
+)
+
+// dma-buf images have at most four planes.
+const maxDmaBufPlanes = 4
+
+// Per plane attributes for EGL_EXT_image_dma_buf_import and its modifiers
+// extension, indexed by plane.
+var dmaBufPlaneAttribs = [maxDmaBufPlanes]struct {
+	fd, offset, pitch, modifierLo, modifierHi Attrib
+}{
+	{C.EGL_DMA_BUF_PLANE0_FD_EXT, C.EGL_DMA_BUF_PLANE0_OFFSET_EXT, C.EGL_DMA_BUF_PLANE0_PITCH_EXT, C.EGL_DMA_BUF_PLANE0_MODIFIER_LO_EXT, C.EGL_DMA_BUF_PLANE0_MODIFIER_HI_EXT},
+	{C.EGL_DMA_BUF_PLANE1_FD_EXT, C.EGL_DMA_BUF_PLANE1_OFFSET_EXT, C.EGL_DMA_BUF_PLANE1_PITCH_EXT, C.EGL_DMA_BUF_PLANE1_MODIFIER_LO_EXT, C.EGL_DMA_BUF_PLANE1_MODIFIER_HI_EXT},
+	{C.EGL_DMA_BUF_PLANE2_FD_EXT, C.EGL_DMA_BUF_PLANE2_OFFSET_EXT, C.EGL_DMA_BUF_PLANE2_PITCH_EXT, C.EGL_DMA_BUF_PLANE2_MODIFIER_LO_EXT, C.EGL_DMA_BUF_PLANE2_MODIFIER_HI_EXT},
+	{C.EGL_DMA_BUF_PLANE3_FD_EXT, C.EGL_DMA_BUF_PLANE3_OFFSET_EXT, C.EGL_DMA_BUF_PLANE3_PITCH_EXT, C.EGL_DMA_BUF_PLANE3_MODIFIER_LO_EXT, C.EGL_DMA_BUF_PLANE3_MODIFIER_HI_EXT},
+}
+
+type DmaBufPlane struct {
+	Fd int
+	Offset uint32
+	Stride uint32
+}
+
+/*
+ * DmaBuf describes a Linux dma-buf image: its size, DRM fourcc format and
+ * format modifier, and the file descriptor, offset and stride of each
+ * plane. Modifier is only used when HasModifier is set; otherwise the
+ * layout is implied by the driver.
+ */
+type DmaBuf struct {
+	Width, Height int
+	Fourcc uint32
+	Modifier uint64
+	HasModifier bool
+	Planes []DmaBufPlane
+}
+
+type DmaBufModifier struct {
+	Modifier uint64
+	ExternalOnly bool // can only be sampled as GL_TEXTURE_EXTERNAL_OES
+}
+
+// Close closes every plane's file descriptor.
+func (buf DmaBuf) Close() error {
+	var result error
+	for _, plane := range(buf.Planes) {
+		closeErr := syscall.Close(plane.Fd)
+		if closeErr != nil && result == nil {
+			result = closeErr
+		}
+	}
+	return result
+}
+
+/*
+ * attribList builds the eglCreateImage attributes for importing buf. The
+ * modifier is only included when HasModifier is set.
+ */
+func (buf DmaBuf) attribList() ([]Attrib, error) {
+	if buf.Width <= 0 || buf.Height <= 0 {
+		return nil, errors.New("dma-buf width and height must be positive")
+	}
+	if len(buf.Planes) == 0 || len(buf.Planes) > maxDmaBufPlanes {
+		return nil, fmt.Errorf("dma-buf has %d planes, expected 1 to %d", len(buf.Planes), maxDmaBufPlanes)
+	}
+
+	attribList := []Attrib{
+		Width, Attrib(buf.Width),
+		Height, Attrib(buf.Height),
+		C.EGL_LINUX_DRM_FOURCC_EXT, Attrib(int32(buf.Fourcc)),
+	}
+	for i, plane := range(buf.Planes) {
+		names := dmaBufPlaneAttribs[i]
+		attribList = append(attribList,
+			names.fd, Attrib(plane.Fd),
+			names.offset, Attrib(int32(plane.Offset)),
+			names.pitch, Attrib(int32(plane.Stride)))
+		if buf.HasModifier {
+			attribList = append(attribList,
+				names.modifierLo, Attrib(int32(uint32(buf.Modifier))),
+				names.modifierHi, Attrib(int32(uint32(buf.Modifier >> 32))))
+		}
+	}
+	return append(attribList, None), nil
+}
+
+/*
+ * CreateImageFromDmaBuf imports buf as an image. EGL duplicates what it
+ * needs, so buf's file descriptors can be closed afterward. Requires
+ * EGL_EXT_image_dma_buf_import, and EGL_EXT_image_dma_buf_import_modifiers
+ * when buf has a modifier.
+ */
+func (display *Display) CreateImageFromDmaBuf(buf DmaBuf) (*Image, error) {
+	if !display.HasExtension("EGL_EXT_image_dma_buf_import") {
+		return nil, errors.New("dma-buf images require EGL_EXT_image_dma_buf_import")
+	}
+	if buf.HasModifier && !display.HasExtension("EGL_EXT_image_dma_buf_import_modifiers") {
+		return nil, errors.New("dma-buf modifiers require EGL_EXT_image_dma_buf_import_modifiers")
+	}
+	attribList, attribErr := buf.attribList()
+	if attribErr != nil {
+		return nil, attribErr
+	}
+	eglImage, imageErr := display.createImage(nil, C.EGL_LINUX_DMA_BUF_EXT, 0, attribList)
+	if imageErr != nil {
+		return nil, imageErr
+	}
+	eglImage.width, eglImage.height = buf.Width, buf.Height
+	return eglImage, nil
+}
+
+func (display *Display) dmaBufQueryFunc(name string) (unsafe.Pointer, error) {
+	if !display.HasExtension("EGL_EXT_image_dma_buf_import_modifiers") {
+		return nil, errors.New("dma-buf queries require EGL_EXT_image_dma_buf_import_modifiers")
+	}
+	function := procAddress(name)
+	if function == nil {
+		return nil, fmt.Errorf("%s could not be resolved", name)
+	}
+	return function, nil
+}
+
+// QueryDmaBufFormats lists the DRM fourcc formats that can be imported.
+func (display *Display) QueryDmaBufFormats() ([]uint32, error) {
+	function, functionErr := display.dmaBufQueryFunc("eglQueryDmaBufFormatsEXT")
+	if functionErr != nil {
+		return nil, functionErr
+	}
+
+	var count C.EGLint
+	success := C.callQueryDmaBufFormats(function, display.eglDisplay, 0, nil, &count)
+	if success == C.EGL_FALSE {
+		return nil, getError()
+	}
+	if count == 0 {
+		return nil, nil
+	}
+	formats := make([]C.EGLint, count)
+	success = C.callQueryDmaBufFormats(function, display.eglDisplay, count, &formats[0], &count)
+	if success == C.EGL_FALSE {
+		return nil, getError()
+	}
+
+	fourccs := make([]uint32, count)
+	for i := range(fourccs) {
+		fourccs[i] = uint32(formats[i])
+	}
+	return fourccs, nil
+}
+
+// QueryDmaBufModifiers lists the modifiers format can be imported with.
+func (display *Display) QueryDmaBufModifiers(format uint32) ([]DmaBufModifier, error) {
+	function, functionErr := display.dmaBufQueryFunc("eglQueryDmaBufModifiersEXT")
+	if functionErr != nil {
+		return nil, functionErr
+	}
+
+	var count C.EGLint
+	success := C.callQueryDmaBufModifiers(function, display.eglDisplay, C.EGLint(int32(format)), 0, nil, nil, &count)
+	if success == C.EGL_FALSE {
+		return nil, getError()
+	}
+	if count == 0 {
+		return nil, nil
+	}
+	modifiers := make([]C.EGLuint64KHR, count)
+	externalOnly := make([]C.EGLBoolean, count)
+	success = C.callQueryDmaBufModifiers(function, display.eglDisplay, C.EGLint(int32(format)), count, &modifiers[0], &externalOnly[0], &count)
+	if success == C.EGL_FALSE {
+		return nil, getError()
+	}
+
+	result := make([]DmaBufModifier, count)
+	for i := range(result) {
+		result[i].Modifier = uint64(modifiers[i])
+		result[i].ExternalOnly = externalOnly[i] != C.EGL_FALSE
+	}
+	return result, nil
+}
+
+/*
+ * ExportDmaBuf exports the image as new dma-buf file descriptors, which
+ * the caller must close. EGL doesn't report the image's size, so Width and
+ * Height are those the image was created with, and are left zero for the
+ * caller to fill in for images of GL textures and renderbuffers. Requires
+ * EGL_MESA_image_dma_buf_export.
+ */
+func (eglImage *Image) ExportDmaBuf() (DmaBuf, error) {
+	var buf DmaBuf
+	display := eglImage.Display
+	if !display.HasExtension("EGL_MESA_image_dma_buf_export") {
+		return buf, errors.New("dma-buf export requires EGL_MESA_image_dma_buf_export")
+	}
+	if eglImage.eglImage == nil {
+		return buf, errors.New("image has been destroyed")
+	}
+	queryFunction := procAddress("eglExportDMABUFImageQueryMESA")
+	exportFunction := procAddress("eglExportDMABUFImageMESA")
+	if queryFunction == nil || exportFunction == nil {
+		return buf, errors.New("eglGetProcAddress could not resolve the dma-buf export functions")
+	}
+
+	var fourcc, planeCount C.int
+	var modifiers [maxDmaBufPlanes]C.EGLuint64KHR
+	// left alone by drivers that don't use modifiers
+	for i := range(modifiers) {
+		modifiers[i] = DrmFormatModInvalid
+	}
+	success := C.callExportQuery(queryFunction, display.eglDisplay, eglImage.eglImage, &fourcc, &planeCount, &modifiers[0])
+	if success == C.EGL_FALSE {
+		return buf, getError()
+	}
+	if planeCount <= 0 || planeCount > maxDmaBufPlanes {
+		return buf, fmt.Errorf("image has %d planes, expected 1 to %d", planeCount, maxDmaBufPlanes)
+	}
+
+	var fds [maxDmaBufPlanes]C.int
+	var strides, offsets [maxDmaBufPlanes]C.EGLint
+	success = C.callExport(exportFunction, display.eglDisplay, eglImage.eglImage, &fds[0], &strides[0], &offsets[0])
+	if success == C.EGL_FALSE {
+		return buf, getError()
+	}
+
+	buf.Width, buf.Height = eglImage.width, eglImage.height
+	buf.Fourcc = uint32(fourcc)
+	if modifiers[0] != DrmFormatModInvalid {
+		buf.Modifier = uint64(modifiers[0])
+		buf.HasModifier = true
+	}
+	for i := 0; i < int(planeCount); i++ {
+		plane := DmaBufPlane{
+			Fd: int(fds[i]),
+			Offset: uint32(offsets[i]),
+			Stride: uint32(strides[i]),
+		}
+		buf.Planes = append(buf.Planes, plane)
+	}
+	return buf, nil
+}
+
+/*
+ * dma-bufs are sent over unix sockets as one message: this header followed
+ * by an offset and stride per plane, all little-endian, with the planes'
+ * file descriptors attached as SCM_RIGHTS. A buffer without a modifier is
+ * sent with DrmFormatModInvalid.
+ */
+const (
+	dmaBufMagic = 0x46424D44 // "DMBF"
+	dmaBufHeaderSize = 4 + 4 + 4 + 4 + 8 + 4
+	dmaBufPlaneSize = 4 + 4
+)
+
+func marshalDmaBuf(buf DmaBuf) ([]byte, []int, error) {
+	if len(buf.Planes) == 0 || len(buf.Planes) > maxDmaBufPlanes {
+		return nil, nil, fmt.Errorf("dma-buf has %d planes, expected 1 to %d", len(buf.Planes), maxDmaBufPlanes)
+	}
+
+	data := make([]byte, dmaBufHeaderSize + len(buf.Planes) * dmaBufPlaneSize)
+	binary.LittleEndian.PutUint32(data[0:], dmaBufMagic)
+	binary.LittleEndian.PutUint32(data[4:], uint32(buf.Width))
+	binary.LittleEndian.PutUint32(data[8:], uint32(buf.Height))
+	binary.LittleEndian.PutUint32(data[12:], buf.Fourcc)
+	modifier := uint64(DrmFormatModInvalid)
+	if buf.HasModifier {
+		modifier = buf.Modifier
+	}
+	binary.LittleEndian.PutUint64(data[16:], modifier)
+	binary.LittleEndian.PutUint32(data[24:], uint32(len(buf.Planes)))
+
+	fds := make([]int, len(buf.Planes))
+	for i, plane := range(buf.Planes) {
+		offset := dmaBufHeaderSize + i * dmaBufPlaneSize
+		binary.LittleEndian.PutUint32(data[offset:], plane.Offset)
+		binary.LittleEndian.PutUint32(data[offset + 4:], plane.Stride)
+		fds[i] = plane.Fd
+	}
+	return data, fds, nil
+}
+
+func unmarshalDmaBuf(data []byte, fds []int) (DmaBuf, error) {
+	var buf DmaBuf
+	if len(data) < dmaBufHeaderSize || binary.LittleEndian.Uint32(data[0:]) != dmaBufMagic {
+		return buf, errors.New("message is not a dma-buf description")
+	}
+	planeCount := int(binary.LittleEndian.Uint32(data[24:]))
+	if planeCount <= 0 || planeCount > maxDmaBufPlanes {
+		return buf, fmt.Errorf("dma-buf has %d planes, expected 1 to %d", planeCount, maxDmaBufPlanes)
+	}
+	if len(data) != dmaBufHeaderSize + planeCount * dmaBufPlaneSize {
+		return buf, errors.New("dma-buf description has the wrong length")
+	}
+	if len(fds) != planeCount {
+		return buf, fmt.Errorf("dma-buf has %d planes but %d file descriptors were received", planeCount, len(fds))
+	}
+
+	buf.Width = int(binary.LittleEndian.Uint32(data[4:]))
+	buf.Height = int(binary.LittleEndian.Uint32(data[8:]))
+	buf.Fourcc = binary.LittleEndian.Uint32(data[12:])
+	modifier := binary.LittleEndian.Uint64(data[16:])
+	if modifier != DrmFormatModInvalid {
+		buf.Modifier = modifier
+		buf.HasModifier = true
+	}
+	for i := 0; i < planeCount; i++ {
+		offset := dmaBufHeaderSize + i * dmaBufPlaneSize
+		plane := DmaBufPlane{
+			Fd: fds[i],
+			Offset: binary.LittleEndian.Uint32(data[offset:]),
+			Stride: binary.LittleEndian.Uint32(data[offset + 4:]),
+		}
+		buf.Planes = append(buf.Planes, plane)
+	}
+	return buf, nil
+}
+
+/*
+ * SendDmaBuf sends buf's description and file descriptors over conn. The
+ * descriptors stay open in this process.
+ */
+func SendDmaBuf(conn *net.UnixConn, buf DmaBuf) error {
+	data, fds, marshalErr := marshalDmaBuf(buf)
+	if marshalErr != nil {
+		return marshalErr
+	}
+	_, _, writeErr := conn.WriteMsgUnix(data, syscall.UnixRights(fds...), nil)
+	return writeErr
+}
+
+/*
+ * ReceiveDmaBuf receives a dma-buf sent with SendDmaBuf. The caller owns
+ * the new file descriptors and should Close the DmaBuf when done with it.
+ */
+func ReceiveDmaBuf(conn *net.UnixConn) (DmaBuf, error) {
+	data := make([]byte, dmaBufHeaderSize + maxDmaBufPlanes * dmaBufPlaneSize)
+	oob := make([]byte, syscall.CmsgSpace(maxDmaBufPlanes * 4))
+	n, oobn, flags, _, readErr := conn.ReadMsgUnix(data, oob)
+	if readErr != nil {
+		return DmaBuf{}, readErr
+	}
+
+	var fds []int
+	messages, parseErr := syscall.ParseSocketControlMessage(oob[:oobn])
+	if parseErr != nil {
+		return DmaBuf{}, parseErr
+	}
+	for _, message := range(messages) {
+		rights, rightsErr := syscall.ParseUnixRights(&message)
+		if rightsErr != nil {
+			continue
+		}
+		fds = append(fds, rights...)
+	}
+
+	var buf DmaBuf
+	var result error
+	switch {
+		case flags & syscall.MSG_CTRUNC != 0:
+			// the kernel closed the descriptors that didn't fit
+			result = errors.New("dma-buf file descriptors were truncated")
+		case flags & syscall.MSG_TRUNC != 0:
+			result = errors.New("dma-buf description was truncated")
+		default:
+			buf, result = unmarshalDmaBuf(data[:n], fds)
+	}
+	if result != nil {
+		for _, fd := range(fds) {
+			syscall.Close(fd)
+		}
+		return DmaBuf{}, result
+	}
+	return buf, nil
+}
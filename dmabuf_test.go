@@ -0,0 +1,231 @@
+package egl
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+// memfd_create isn't in the syscall package on every architecture.
+var memfdCreateSyscall = map[string]uintptr{
+	"386": 356,
+	"amd64": 319,
+	"arm": 385,
+	"arm64": 279,
+	"loong64": 279,
+	"ppc64le": 360,
+	"riscv64": 279,
+	"s390x": 350,
+}
+
+// newTestMemfd returns a memfd holding contents, closed when the test ends.
+func newTestMemfd(tb testing.TB, contents []byte) int {
+	number, ok := memfdCreateSyscall[runtime.GOARCH]
+	if !ok {
+		tb.Skipf("memfd_create number unknown on %s", runtime.GOARCH)
+	}
+	name := []byte("egl-test\x00")
+	fd, _, errno := syscall.Syscall(number, uintptr(unsafe.Pointer(&name[0])), 0, 0)
+	if errno != 0 {
+		tb.Skip("memfd_create:", errno)
+	}
+	_, writeErr := syscall.Pwrite(int(fd), contents, 0)
+	if writeErr != nil {
+		syscall.Close(int(fd))
+		tb.Fatal(writeErr)
+	}
+	tb.Cleanup(func() { syscall.Close(int(fd)) })
+	return int(fd)
+}
+
+func readTestFd(tb testing.TB, fd, length int) []byte {
+	contents := make([]byte, length)
+	n, readErr := syscall.Pread(fd, contents, 0)
+	if readErr != nil {
+		tb.Fatal(readErr)
+	}
+	return contents[:n]
+}
+
+// newTestSocketPair returns both ends of a SOCK_SEQPACKET unix socket pair.
+func newTestSocketPair(tb testing.TB) (*net.UnixConn, *net.UnixConn) {
+	fds, pairErr := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_SEQPACKET | syscall.SOCK_CLOEXEC, 0)
+	if pairErr != nil {
+		tb.Fatal(pairErr)
+	}
+	var conns [2]*net.UnixConn
+	for i, fd := range(fds) {
+		file := os.NewFile(uintptr(fd), fmt.Sprint("socket", i))
+		conn, connErr := net.FileConn(file)
+		file.Close()
+		if connErr != nil {
+			tb.Fatal(connErr)
+		}
+		conns[i] = conn.(*net.UnixConn)
+		tb.Cleanup(func() { conn.Close() })
+	}
+	return conns[0], conns[1]
+}
+
+// newTestDmaBuf describes a two plane buffer backed by memfds.
+func newTestDmaBuf(tb testing.TB) (DmaBuf, [][]byte) {
+	contents := [][]byte{
+		[]byte("luma plane"),
+		[]byte("chroma plane"),
+	}
+	buf := DmaBuf{
+		Width: 64,
+		Height: 48,
+		Fourcc: 0x3231564E, // NV12
+		Modifier: DrmFormatModLinear,
+		HasModifier: true,
+	}
+	for i, planeContents := range(contents) {
+		plane := DmaBufPlane{
+			Fd: newTestMemfd(tb, planeContents),
+			Offset: uint32(i * 4096),
+			Stride: 64,
+		}
+		buf.Planes = append(buf.Planes, plane)
+	}
+	return buf, contents
+}
+
+func checkTestDmaBuf(tb testing.TB, got, want DmaBuf, contents [][]byte) {
+	if got.Width != want.Width || got.Height != want.Height || got.Fourcc != want.Fourcc ||
+		got.Modifier != want.Modifier || got.HasModifier != want.HasModifier {
+		tb.Errorf("got %dx%d fourcc 0x%X modifier 0x%X (%t), want %dx%d fourcc 0x%X modifier 0x%X (%t)",
+			got.Width, got.Height, got.Fourcc, got.Modifier, got.HasModifier,
+			want.Width, want.Height, want.Fourcc, want.Modifier, want.HasModifier)
+	}
+	if len(got.Planes) != len(want.Planes) {
+		tb.Fatalf("got %d planes, want %d", len(got.Planes), len(want.Planes))
+	}
+	for i, plane := range(got.Planes) {
+		if plane.Offset != want.Planes[i].Offset || plane.Stride != want.Planes[i].Stride {
+			tb.Errorf("plane %d has offset %d stride %d, want %d and %d", i,
+				plane.Offset, plane.Stride, want.Planes[i].Offset, want.Planes[i].Stride)
+		}
+		read := readTestFd(tb, plane.Fd, len(contents[i]) + 1)
+		if !bytes.Equal(read, contents[i]) {
+			tb.Errorf("plane %d holds %q, want %q", i, read, contents[i])
+		}
+	}
+}
+
+func TestMarshalDmaBuf(t *testing.T) {
+	buf, contents := newTestDmaBuf(t)
+	data, fds, marshalErr := marshalDmaBuf(buf)
+	if marshalErr != nil {
+		t.Fatal(marshalErr)
+	}
+	got, unmarshalErr := unmarshalDmaBuf(data, fds)
+	if unmarshalErr != nil {
+		t.Fatal(unmarshalErr)
+	}
+	checkTestDmaBuf(t, got, buf, contents)
+
+	_, shortErr := unmarshalDmaBuf(data[:len(data) - 1], fds)
+	if shortErr == nil {
+		t.Error("unmarshalDmaBuf accepted a truncated description")
+	}
+	_, fdsErr := unmarshalDmaBuf(data, fds[:1])
+	if fdsErr == nil {
+		t.Error("unmarshalDmaBuf accepted too few file descriptors")
+	}
+	_, _, emptyErr := marshalDmaBuf(DmaBuf{})
+	if emptyErr == nil {
+		t.Error("marshalDmaBuf accepted a buffer without planes")
+	}
+}
+
+func TestDmaBufModifier(t *testing.T) {
+	buf, contents := newTestDmaBuf(t)
+	buf.Modifier, buf.HasModifier = 0, false
+	attribList, attribErr := buf.attribList()
+	if attribErr != nil {
+		t.Fatal(attribErr)
+	}
+	for i := 0; i + 1 < len(attribList); i += 2 {
+		if attribList[i] == dmaBufPlaneAttribs[0].modifierLo || attribList[i] == dmaBufPlaneAttribs[0].modifierHi {
+			t.Error("attribList included a modifier that wasn't set")
+		}
+	}
+
+	data, fds, marshalErr := marshalDmaBuf(buf)
+	if marshalErr != nil {
+		t.Fatal(marshalErr)
+	}
+	got, unmarshalErr := unmarshalDmaBuf(data, fds)
+	if unmarshalErr != nil {
+		t.Fatal(unmarshalErr)
+	}
+	checkTestDmaBuf(t, got, buf, contents)
+
+	buf.HasModifier = true
+	attribList, attribErr = buf.attribList()
+	if attribErr != nil {
+		t.Fatal(attribErr)
+	}
+	found := false
+	for i := 0; i + 1 < len(attribList); i += 2 {
+		if attribList[i] == dmaBufPlaneAttribs[0].modifierLo && attribList[i + 1] == DrmFormatModLinear {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("attribList left out the linear modifier")
+	}
+}
+
+func TestSendDmaBuf(t *testing.T) {
+	buf, contents := newTestDmaBuf(t)
+	sender, receiver := newTestSocketPair(t)
+
+	sendErr := SendDmaBuf(sender, buf)
+	if sendErr != nil {
+		t.Fatal(sendErr)
+	}
+	got, receiveErr := ReceiveDmaBuf(receiver)
+	if receiveErr != nil {
+		t.Fatal(receiveErr)
+	}
+	defer got.Close()
+
+	for i, plane := range(got.Planes) {
+		if plane.Fd == buf.Planes[i].Fd {
+			t.Errorf("plane %d kept the sender's file descriptor", i)
+		}
+	}
+	checkTestDmaBuf(t, got, buf, contents)
+}
+
+func TestReceiveDmaBufTruncated(t *testing.T) {
+	buf, _ := newTestDmaBuf(t)
+	sender, receiver := newTestSocketPair(t)
+
+	// a full set of planes, so the descriptors that fit would look valid,
+	// plus one more than ReceiveDmaBuf has room for
+	for len(buf.Planes) < maxDmaBufPlanes {
+		buf.Planes = append(buf.Planes, buf.Planes[0])
+	}
+	data, fds, marshalErr := marshalDmaBuf(buf)
+	if marshalErr != nil {
+		t.Fatal(marshalErr)
+	}
+	fds = append(fds, buf.Planes[0].Fd)
+	_, _, writeErr := sender.WriteMsgUnix(data, syscall.UnixRights(fds...), nil)
+	if writeErr != nil {
+		t.Fatal(writeErr)
+	}
+
+	_, receiveErr := ReceiveDmaBuf(receiver)
+	if receiveErr == nil {
+		t.Error("ReceiveDmaBuf accepted truncated file descriptors")
+	}
+}
@@ -0,0 +1,348 @@
+package egl
+
+/*
+#cgo pkg-config: egl x11
+
+#include <EGL/egl.h>
+#include <EGL/eglext.h>
+#include <X11/Xlib.h>
+#include <stdint.h>
+
+// The attribute list is EGLAttrib for core EGL 1.5 and EGLint for
+// EGL_KHR_image_base. Buffers are GL object names or X IDs, passed as
+// integers so Go never holds them as pointers.
+typedef EGLImage (*createImageFunc)(EGLDisplay display, EGLContext context, EGLenum target, EGLClientBuffer buffer, const void *attribList);
+typedef EGLBoolean (*destroyImageFunc)(EGLDisplay display, EGLImage image);
+typedef void (*imageTargetFunc)(unsigned int target, void *image);
+
+static EGLImage callCreateImage(void *function, EGLDisplay display, EGLContext context, EGLenum target, uintptr_t buffer, const void *attribList) {
+	return ((createImageFunc)function)(display, context, target, (EGLClientBuffer)buffer, attribList);
+}
+
+static EGLBoolean callDestroyImage(void *function, EGLDisplay display, EGLImage image) {
+	return ((destroyImageFunc)function)(display, image);
+}
+
+static void callImageTarget(void *function, unsigned int target, EGLImage image) {
+	((imageTargetFunc)function)(target, image);
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+)
+
+// GL enums used to attach images
+const (
+	glTexture2D = 0x0DE1
+	glRenderbuffer = 0x8D41
+)
+
+/*
+ * Image is an EGLImage, a 2D image that GL textures and renderbuffers in
+ * different contexts can share even when the contexts aren't in the same
+ * share group. Images are reference counted: they start with one reference
+ * and are destroyed when the last is released, or when their Display is
+ * closed.
+ */
+type Image struct {
+	Display *Display
+	eglImage C.EGLImage
+	destroyFunc unsafe.Pointer
+	xPixmap C.Pixmap // created for the image and freed with it
+	width, height int // zero for GL images, whose size EGL doesn't know
+	refs int // guarded by Display.imagesLock
+}
+
+type imageFuncs struct {
+	create unsafe.Pointer
+	destroy unsafe.Pointer
+	khr bool
+}
+
+/*
+ * resolveImageFuncs finds eglCreateImage and eglDestroyImage, from core EGL
+ * 1.5 if available and EGL_KHR_image_base otherwise.
+ */
+func (display *Display) resolveImageFuncs() (imageFuncs, error) {
+	var funcs imageFuncs
+	major, minor := display.GetVersion()
+	if major > 1 || major == 1 && minor >= 5 {
+		funcs.create = procAddress("eglCreateImage")
+		funcs.destroy = procAddress("eglDestroyImage")
+	} else if display.HasExtension("EGL_KHR_image_base") {
+		funcs.create = procAddress("eglCreateImageKHR")
+		funcs.destroy = procAddress("eglDestroyImageKHR")
+		funcs.khr = true
+	} else {
+		return funcs, errors.New("images require EGL 1.5 or EGL_KHR_image_base")
+	}
+	if funcs.create == nil || funcs.destroy == nil {
+		return funcs, errors.New("eglGetProcAddress could not resolve the image functions")
+	}
+	return funcs, nil
+}
+
+func (display *Display) createImage(context *Context, target Attrib, buffer uintptr, attribList []Attrib) (*Image, error) {
+	funcs, funcsErr := display.resolveImageFuncs()
+	if funcsErr != nil {
+		return nil, funcsErr
+	}
+
+	eglContext := noContext
+	if context != nil {
+		if context.Display != display {
+			return nil, errors.New("context belongs to a different display")
+		}
+		eglContext = context.eglContext
+	}
+
+	var eglAttribs unsafe.Pointer
+	if funcs.khr {
+		khrAttribs := append(stripNone(attribList), None)
+		eglAttribs = unsafe.Pointer(&khrAttribs[0])
+	} else {
+		var coreAttribs []C.EGLAttrib
+		for _, value := range(stripNone(attribList)) {
+			coreAttribs = append(coreAttribs, C.EGLAttrib(value))
+		}
+		coreAttribs = append(coreAttribs, None)
+		eglAttribs = unsafe.Pointer(&coreAttribs[0])
+	}
+
+	handle := C.callCreateImage(funcs.create, display.eglDisplay, eglContext, C.EGLenum(target), C.uintptr_t(buffer), eglAttribs)
+	if handle == nil {
+		return nil, getError()
+	}
+
+	eglImage := new(Image)
+	eglImage.Display = display
+	eglImage.eglImage = handle
+	eglImage.destroyFunc = funcs.destroy
+	eglImage.refs = 1
+
+	display.imagesLock.Lock()
+	if display.images == nil {
+		display.images = make(map[*Image]bool)
+	}
+	display.images[eglImage] = true
+	display.imagesLock.Unlock()
+	return eglImage, nil
+}
+
+// stripNone copies attribList up to its None terminator.
+func stripNone(attribList []Attrib) []Attrib {
+	var stripped []Attrib
+	for i := 0; i + 1 < len(attribList); i += 2 {
+		if attribList[i] == None {
+			break
+		}
+		stripped = append(stripped, attribList[i], attribList[i + 1])
+	}
+	return stripped
+}
+
+/*
+ * CreateImageFromTexture makes an image of level of the GL_TEXTURE_2D
+ * texture named texture in context. Requires EGL_KHR_gl_texture_2D_image.
+ */
+func (display *Display) CreateImageFromTexture(context *Context, texture uint32, level int) (*Image, error) {
+	if !display.HasExtension("EGL_KHR_gl_texture_2D_image") {
+		return nil, errors.New("texture images require EGL_KHR_gl_texture_2D_image")
+	}
+	if context == nil || texture == 0 {
+		return nil, errors.New("texture images require a context and a nonzero texture name")
+	}
+	attribList := []Attrib{
+		C.EGL_GL_TEXTURE_LEVEL, Attrib(level),
+		C.EGL_IMAGE_PRESERVED, C.EGL_TRUE,
+		None,
+	}
+	return display.createImage(context, C.EGL_GL_TEXTURE_2D, uintptr(texture), attribList)
+}
+
+/*
+ * CreateImageFromRenderbuffer makes an image of the GL renderbuffer named
+ * renderbuffer in context. Requires EGL_KHR_gl_renderbuffer_image.
+ */
+func (display *Display) CreateImageFromRenderbuffer(context *Context, renderbuffer uint32) (*Image, error) {
+	if !display.HasExtension("EGL_KHR_gl_renderbuffer_image") {
+		return nil, errors.New("renderbuffer images require EGL_KHR_gl_renderbuffer_image")
+	}
+	if context == nil || renderbuffer == 0 {
+		return nil, errors.New("renderbuffer images require a context and a nonzero renderbuffer name")
+	}
+	attribList := []Attrib{C.EGL_IMAGE_PRESERVED, C.EGL_TRUE, None}
+	return display.createImage(context, C.EGL_GL_RENDERBUFFER, uintptr(renderbuffer), attribList)
+}
+
+/*
+ * CreatePixmapImage creates a width by height X pixmap at the depth of
+ * config's visual, as CreatePixmapSurfaceWithOptions does, and makes an
+ * image of it. The pixmap is freed with the image. Requires
+ * EGL_KHR_image_pixmap.
+ */
+func (display *Display) CreatePixmapImage(config Config, width, height int, options PixmapOptions) (*Image, error) {
+	if display.xDisplay == nil {
+		return nil, errors.New("pixmap images require a display opened on an X server")
+	}
+	if !display.HasExtension("EGL_KHR_image_pixmap") {
+		return nil, errors.New("pixmap images require EGL_KHR_image_pixmap")
+	}
+	if width <= 0 || height <= 0 {
+		return nil, errors.New("pixmap width and height must be positive")
+	}
+
+	screen, screenErr := display.screen(options.Screen)
+	if screenErr != nil {
+		return nil, screenErr
+	}
+	_, depth, visualErr := display.pixmapVisual(config, screen)
+	if visualErr != nil {
+		return nil, visualErr
+	}
+
+	rootWindow := C.XRootWindow(display.xDisplay, screen)
+	pixmap := C.XCreatePixmap(display.xDisplay, C.Drawable(rootWindow), C.uint(width), C.uint(height), C.uint(depth))
+	eglImage, imageErr := display.createPixmapImage(pixmap, width, height)
+	if imageErr != nil {
+		C.XFreePixmap(display.xDisplay, pixmap)
+		return nil, imageErr
+	}
+	eglImage.xPixmap = pixmap
+	return eglImage, nil
+}
+
+/*
+ * CreateImageFromPixmap makes an image of an existing X pixmap, such as one
+ * from XCompositeNameWindowPixmap. The pixmap still belongs to the caller
+ * and must outlive the image. Requires EGL_KHR_image_pixmap.
+ */
+func (display *Display) CreateImageFromPixmap(pixmap NativePixmap) (*Image, error) {
+	if display.xDisplay == nil {
+		return nil, errors.New("pixmap images require a display opened on an X server")
+	}
+	if !display.HasExtension("EGL_KHR_image_pixmap") {
+		return nil, errors.New("pixmap images require EGL_KHR_image_pixmap")
+	}
+
+	var root C.Window
+	var x, y C.int
+	var width, height, border, depth C.uint
+	status := C.XGetGeometry(display.xDisplay, C.Drawable(pixmap), &root, &x, &y, &width, &height, &border, &depth)
+	if status == 0 {
+		return nil, errors.New("XGetGeometry failed, pixmap may not exist")
+	}
+	return display.createPixmapImage(C.Pixmap(pixmap), int(width), int(height))
+}
+
+func (display *Display) createPixmapImage(pixmap C.Pixmap, width, height int) (*Image, error) {
+	attribList := []Attrib{C.EGL_IMAGE_PRESERVED, C.EGL_TRUE, None}
+	eglImage, imageErr := display.createImage(nil, C.EGL_NATIVE_PIXMAP_KHR, uintptr(pixmap), attribList)
+	if imageErr != nil {
+		return nil, imageErr
+	}
+	eglImage.width, eglImage.height = width, height
+	return eglImage, nil
+}
+
+// Retain adds a reference, which must be balanced by a call to Release.
+func (eglImage *Image) Retain() error {
+	display := eglImage.Display
+	display.imagesLock.Lock()
+	defer display.imagesLock.Unlock()
+	if eglImage.refs <= 0 {
+		return errors.New("image has been destroyed")
+	}
+	eglImage.refs++
+	return nil
+}
+
+/*
+ * Release drops a reference, destroying the image when none are left.
+ * Textures and renderbuffers it was bound to keep their contents.
+ */
+func (eglImage *Image) Release() error {
+	display := eglImage.Display
+	display.imagesLock.Lock()
+	defer display.imagesLock.Unlock()
+	if eglImage.refs <= 0 {
+		return errors.New("image has been destroyed")
+	}
+	eglImage.refs--
+	if eglImage.refs > 0 {
+		return nil
+	}
+	return eglImage.destroy()
+}
+
+// destroy frees the image. The display's imagesLock must be held.
+func (eglImage *Image) destroy() error {
+	display := eglImage.Display
+	delete(display.images, eglImage)
+	eglImage.refs = 0
+
+	var result error
+	success := C.callDestroyImage(eglImage.destroyFunc, display.eglDisplay, eglImage.eglImage)
+	if success == C.EGL_FALSE {
+		result = getError()
+	}
+	eglImage.eglImage = nil
+
+	if eglImage.xPixmap != 0 {
+		C.XFreePixmap(display.xDisplay, eglImage.xPixmap)
+		eglImage.xPixmap = 0
+	}
+	return result
+}
+
+// destroyImages frees every image still alive, whatever its references.
+func (display *Display) destroyImages() {
+	display.imagesLock.Lock()
+	defer display.imagesLock.Unlock()
+	for eglImage := range(display.images) {
+		eglImage.destroy()
+	}
+}
+
+/*
+ * BindTexture2D makes the image the storage of the texture bound to
+ * GL_TEXTURE_2D in the calling thread's current context, using
+ * glEGLImageTargetTexture2DOES.
+ */
+func (eglImage *Image) BindTexture2D() error {
+	return eglImage.bindTarget("glEGLImageTargetTexture2DOES", glTexture2D)
+}
+
+/*
+ * BindRenderbuffer makes the image the storage of the currently bound
+ * renderbuffer, using glEGLImageTargetRenderbufferStorageOES.
+ */
+func (eglImage *Image) BindRenderbuffer() error {
+	return eglImage.bindTarget("glEGLImageTargetRenderbufferStorageOES", glRenderbuffer)
+}
+
+func (eglImage *Image) bindTarget(name string, target C.uint) error {
+	if eglImage.eglImage == nil {
+		return errors.New("image has been destroyed")
+	}
+	// glGetError is core, so only resolvable with these
+	if !eglImage.Display.resolvesCoreGL() {
+		return errors.New("binding images requires EGL 1.5 or EGL_KHR_get_all_proc_addresses")
+	}
+	function := procAddress(name)
+	if function == nil {
+		return fmt.Errorf("%s could not be resolved", name)
+	}
+	funcs := glReadFuncs{getError: procAddress("glGetError")}
+	if funcs.getError == nil {
+		return errors.New("glGetError could not be resolved")
+	}
+
+	funcs.clearErrors()
+	C.callImageTarget(function, target, eglImage.eglImage)
+	return funcs.checkError(name)
+}
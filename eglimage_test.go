@@ -0,0 +1,35 @@
+package egl
+
+import (
+	"testing"
+)
+
+func TestBindTextureStaleError(t *testing.T) {
+	gl := currentTestGL(t)
+	display := gl.Context.Display
+	if !display.HasExtension("EGL_KHR_gl_texture_2D_image") {
+		t.Skip("EGL_KHR_gl_texture_2D_image is not supported")
+	}
+
+	var textures [2]uint32
+	gl.GenTextures(textures[:])
+	defer gl.DeleteTextures(textures[:])
+	gl.BindTexture(GLTexture2D, textures[0])
+	texErr := gl.TexImage2D(GLTexture2D, 0, GLRGBA, 4, 4, 0, GLRGBA, GLUnsignedByte, nil)
+	if texErr != nil {
+		t.Fatal(texErr)
+	}
+	eglImage, imageErr := display.CreateImageFromTexture(gl.Context, textures[0], 0)
+	if imageErr != nil {
+		t.Skip(imageErr)
+	}
+	defer eglImage.Release()
+
+	// an error left over from an unrelated call
+	gl.BindTexture(0, textures[1])
+	gl.BindTexture(GLTexture2D, textures[1])
+	bindErr := eglImage.BindTexture2D()
+	if bindErr != nil {
+		t.Error(bindErr)
+	}
+}
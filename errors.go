@@ -13,7 +13,10 @@ import (
 )
 
 func getError() error {
-	errorCode := C.eglGetError()
+	return errorForCode(C.eglGetError())
+}
+
+func errorForCode(errorCode C.EGLint) error {
 	switch errorCode {
 		case C.EGL_SUCCESS:
 			return errors.New("EGL succeeded.")
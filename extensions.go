@@ -0,0 +1,71 @@
+package egl
+
+/*
+#cgo pkg-config: egl
+
+#include <EGL/egl.h>
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+var procAddresses = make(map[string]unsafe.Pointer)
+var procAddressesLock sync.Mutex
+
+/*
+ * HasExtension reports whether name appears in the display's EGL_EXTENSIONS
+ * string. The string is only queried once per display.
+ */
+func (display *Display) HasExtension(name string) bool {
+	display.extensionsOnce.Do(func() {
+		display.extensions = make(map[string]bool)
+		extensions, extensionsErr := display.QueryString(Extensions)
+		if extensionsErr != nil {
+			return
+		}
+		for _, extension := range(strings.Fields(extensions)) {
+			display.extensions[extension] = true
+		}
+	})
+	return display.extensions[name]
+}
+
+/*
+ * resolvesCoreGL reports whether eglGetProcAddress returns core client API
+ * functions as well as extensions, which EGL 1.5 and
+ * EGL_KHR_get_all_proc_addresses promise. Both also make the addresses
+ * independent of the context, so procAddress can cache them.
+ */
+func (display *Display) resolvesCoreGL() bool {
+	if display.majorVersion > 1 || (display.majorVersion == 1 && display.minorVersion >= 5) {
+		return true
+	}
+	return display.HasExtension("EGL_KHR_get_all_proc_addresses")
+}
+
+/*
+ * procAddress resolves an extension or client API function with
+ * eglGetProcAddress. Results are cached, including failures, which are
+ * returned as nil.
+ */
+func procAddress(name string) unsafe.Pointer {
+	procAddressesLock.Lock()
+	defer procAddressesLock.Unlock()
+
+	address, found := procAddresses[name]
+	if found {
+		return address
+	}
+
+	cName := C.CString(name)
+	address = unsafe.Pointer(C.eglGetProcAddress(cName))
+	C.free(unsafe.Pointer(cName))
+
+	procAddresses[name] = address
+	return address
+}
@@ -0,0 +1,133 @@
+package egl
+
+import (
+	"image"
+	"image/color"
+)
+
+/*
+ * FloatColor is a color with float32 components, as read back from
+ * floating point configs. Components may fall outside [0, 1]; RGBA clamps
+ * them. Premultiplied tells whether R, G and B are already multiplied by A.
+ */
+type FloatColor struct {
+	R, G, B, A float32
+	Premultiplied bool
+}
+
+func clampUnit(value float32) uint32 {
+	if value <= 0 || value != value {
+		return 0
+	}
+	if value >= 1 {
+		return 0xFFFF
+	}
+	return uint32(value * 0xFFFF + 0.5)
+}
+
+func (c FloatColor) RGBA() (r, g, b, a uint32) {
+	red, green, blue := c.R, c.G, c.B
+	if !c.Premultiplied {
+		red *= c.A
+		green *= c.A
+		blue *= c.A
+	}
+	return clampUnit(red), clampUnit(green), clampUnit(blue), clampUnit(c.A)
+}
+
+// FloatModel converts any color to a premultiplied FloatColor.
+var FloatModel color.Model = color.ModelFunc(floatModel)
+
+func floatModel(c color.Color) color.Color {
+	if floatColor, ok := c.(FloatColor); ok {
+		return floatColor
+	}
+	r, g, b, a := c.RGBA()
+	return FloatColor{
+		R: float32(r) / 0xFFFF,
+		G: float32(g) / 0xFFFF,
+		B: float32(b) / 0xFFFF,
+		A: float32(a) / 0xFFFF,
+		Premultiplied: true,
+	}
+}
+
+/*
+ * FloatRGBA is an image of FloatColor, laid out like image.RGBA with four
+ * float32 values per pixel and Stride values per row.
+ */
+type FloatRGBA struct {
+	Pix []float32
+	Stride int
+	Rect image.Rectangle
+	Premultiplied bool
+}
+
+func NewFloatRGBA(r image.Rectangle, premultiplied bool) *FloatRGBA {
+	return &FloatRGBA{
+		Pix: make([]float32, 4 * r.Dx() * r.Dy()),
+		Stride: 4 * r.Dx(),
+		Rect: r,
+		Premultiplied: premultiplied,
+	}
+}
+
+func (p *FloatRGBA) ColorModel() color.Model {
+	return FloatModel
+}
+
+func (p *FloatRGBA) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+func (p *FloatRGBA) PixOffset(x, y int) int {
+	return (y - p.Rect.Min.Y) * p.Stride + (x - p.Rect.Min.X) * 4
+}
+
+func (p *FloatRGBA) At(x, y int) color.Color {
+	return p.FloatAt(x, y)
+}
+
+func (p *FloatRGBA) FloatAt(x, y int) FloatColor {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return FloatColor{}
+	}
+	i := p.PixOffset(x, y)
+	s := p.Pix[i:i + 4:i + 4]
+	return FloatColor{s[0], s[1], s[2], s[3], p.Premultiplied}
+}
+
+func (p *FloatRGBA) Set(x, y int, c color.Color) {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return
+	}
+	floatColor := FloatModel.Convert(c).(FloatColor)
+	if floatColor.Premultiplied != p.Premultiplied {
+		if p.Premultiplied {
+			floatColor.R *= floatColor.A
+			floatColor.G *= floatColor.A
+			floatColor.B *= floatColor.A
+		} else if floatColor.A != 0 {
+			floatColor.R /= floatColor.A
+			floatColor.G /= floatColor.A
+			floatColor.B /= floatColor.A
+		}
+	}
+	i := p.PixOffset(x, y)
+	s := p.Pix[i:i + 4:i + 4]
+	s[0], s[1], s[2], s[3] = floatColor.R, floatColor.G, floatColor.B, floatColor.A
+}
+
+func (p *FloatRGBA) SubImage(r image.Rectangle) image.Image {
+	r = r.Intersect(p.Rect)
+	if r.Empty() {
+		return &FloatRGBA{Premultiplied: p.Premultiplied}
+	}
+	i := p.PixOffset(r.Min.X, r.Min.Y)
+	return &FloatRGBA{
+		Pix: p.Pix[i:],
+		Stride: p.Stride,
+		Rect: r,
+		Premultiplied: p.Premultiplied,
+	}
+}
@@ -0,0 +1,132 @@
+package egl
+
+/*
+#cgo pkg-config: egl x11
+
+#include <EGL/egl.h>
+#include <X11/Xlib.h>
+#include <X11/Xutil.h>
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+)
+
+/*
+ * CreatePixmapSurfaceFromPixmap wraps an existing X pixmap, such as another
+ * application's window contents from XCompositeNameWindowPixmap, in a
+ * surface. The pixmap still belongs to the caller: Destroy leaves it alone,
+ * and it must outlive the surface.
+ *
+ * textureFormat is NoTexture for a plain pixmap surface, or TextureRGB or
+ * TextureRGBA to allow binding it with BindTexImage, which requires
+ * EGL_NOK_texture_from_pixmap. Such textures are upside down when the
+ * config's YInverted attribute is true.
+ */
+func (display *Display) CreatePixmapSurfaceFromPixmap(config Config, attribList []Attrib, pixmap NativePixmap, textureFormat Attrib) (*Surface, error) {
+	if display.xDisplay == nil {
+		return nil, errors.New("pixmap surfaces require a display opened on an X server")
+	}
+	colorspaceErr := display.checkColorspace(attribList)
+	if colorspaceErr != nil {
+		return nil, colorspaceErr
+	}
+
+	surfaceAttribs := stripNone(attribList)
+	switch textureFormat {
+		case NoTexture:
+		case TextureRGB, TextureRGBA:
+			if !display.HasExtension("EGL_NOK_texture_from_pixmap") {
+				return nil, errors.New("binding pixmap surfaces as textures requires EGL_NOK_texture_from_pixmap")
+			}
+			surfaceAttribs = append(surfaceAttribs, TextureFormat, textureFormat, TextureTarget, Texture2D)
+		default:
+			return nil, fmt.Errorf("unknown texture format 0x%X, expected NoTexture, TextureRGB or TextureRGBA", int(textureFormat))
+	}
+	surfaceAttribs = append(surfaceAttribs, None)
+
+	xDisplay := display.xDisplay
+	xPixmap := C.Pixmap(pixmap)
+	var root C.Window
+	var x, y C.int
+	var width, height, border, depth C.uint
+	status := C.XGetGeometry(xDisplay, C.Drawable(xPixmap), &root, &x, &y, &width, &height, &border, &depth)
+	if status == 0 {
+		return nil, errors.New("XGetGeometry failed, pixmap may not exist")
+	}
+
+	screen := C.XDefaultScreen(xDisplay)
+	for i := C.int(0); i < C.XScreenCount(xDisplay); i++ {
+		if C.XRootWindow(xDisplay, i) == root {
+			screen = i
+			break
+		}
+	}
+
+	// readback needs a visual as deep as the pixmap, which may not be the
+	// config's
+	visual, visualDepth, visualErr := display.pixmapVisual(config, screen)
+	if visualErr != nil || visualDepth != int(depth) {
+		var info C.XVisualInfo
+		if C.XMatchVisualInfo(xDisplay, screen, C.int(depth), C.TrueColor, &info) == 0 {
+			return nil, fmt.Errorf("no %d-bit TrueColor visual on screen %d for pixmap", depth, screen)
+		}
+		visual, visualDepth = info.visual, int(info.depth)
+	}
+
+	eglSurface := C.eglCreatePixmapSurface(display.eglDisplay, C.EGLConfig(config), C.EGLNativePixmapType(pixmap), (*C.EGLint)(&surfaceAttribs[0]))
+	if eglSurface == noSurface {
+		return nil, getError()
+	}
+
+	surface := new(Surface)
+	surface.Display = display
+	surface.eglSurface = eglSurface
+	surface.config = config
+	surface.xPixmap = xPixmap
+	surface.width = int(width)
+	surface.height = int(height)
+	surface.screen = screen
+	surface.visual = visual
+	surface.depth = visualDepth
+	surface.colorspaceRequested = requestsColorspace(attribList)
+	return surface, nil
+}
+
+// Pixmap returns the X pixmap behind a pixmap surface, or 0 for other
+// surfaces.
+func (surface *Surface) Pixmap() NativePixmap {
+	return NativePixmap(surface.xPixmap)
+}
+
+/*
+ * BindPixmapTexture makes a pixmap surface's contents the image of the
+ * texture bound to GL_TEXTURE_2D in the calling thread's context. Surfaces
+ * created with a texture format are bound with BindTexImage, nil is
+ * returned, and ReleaseTexImage undoes the binding. Otherwise an Image of
+ * the pixmap is made with EGL_KHR_image_pixmap and bound; the caller must
+ * Release it once the texture no longer needs it.
+ */
+func (surface *Surface) BindPixmapTexture() (*Image, error) {
+	if surface.xPixmap == 0 {
+		return nil, errors.New("surface is not a pixmap surface")
+	}
+
+	format, formatErr := surface.Query(TextureFormat)
+	if formatErr == nil && format != NoTexture {
+		return nil, surface.BindTexImage(BackBuffer)
+	}
+
+	eglImage, imageErr := surface.Display.CreateImageFromPixmap(surface.Pixmap())
+	if imageErr != nil {
+		return nil, imageErr
+	}
+	bindErr := eglImage.BindTexture2D()
+	if bindErr != nil {
+		eglImage.Release()
+		return nil, bindErr
+	}
+	return eglImage, nil
+}
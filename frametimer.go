@@ -0,0 +1,215 @@
+package egl
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+/*
+ * FrameTimer records how long each SwapBuffers and CopyBuffers call on a
+ * Surface takes, and the interval between the start of consecutive calls.
+ * Only the most recent frames are kept, in a ring buffer.
+ */
+type FrameTimer struct {
+	lock sync.Mutex
+	durations []time.Duration
+	intervals []time.Duration
+	swapIntervals []int
+	next int
+	count int
+	totalFrames uint64
+	lastStart time.Time
+	refreshPeriod time.Duration
+}
+
+/*
+ * FrameDistribution summarizes a set of durations. Percentiles use the
+ * nearest-rank method.
+ */
+type FrameDistribution struct {
+	Min, Max, Mean time.Duration
+	P50, P90, P95, P99 time.Duration
+}
+
+type FrameStats struct {
+	Frames int // frames in this snapshot
+	TotalFrames uint64 // frames since the timer was enabled or reset
+	Duration FrameDistribution // time spent inside each call
+	Interval FrameDistribution // time between the start of consecutive calls
+
+	// Jitter is the standard deviation of the intervals.
+	Jitter time.Duration
+
+	// DroppedFrames estimates how many refresh periods passed without a
+	// new frame, assuming each frame should take the refresh period times
+	// the surface's swap interval. It is zero when the refresh period is
+	// unknown.
+	DroppedFrames int
+}
+
+func newFrameTimer(capacity int, refreshPeriod time.Duration) *FrameTimer {
+	timer := new(FrameTimer)
+	timer.durations = make([]time.Duration, capacity)
+	timer.intervals = make([]time.Duration, capacity)
+	timer.swapIntervals = make([]int, capacity)
+	timer.refreshPeriod = refreshPeriod
+	return timer
+}
+
+/*
+ * EnableFrameTimer starts timing SwapBuffers and CopyBuffers calls, keeping
+ * the last capacity frames. refreshPeriod is the display's refresh period,
+ * used to estimate dropped frames; pass 0 if it isn't known. Enable and
+ * disable the timer from the thread that presents the surface.
+ */
+func (surface *Surface) EnableFrameTimer(capacity int, refreshPeriod time.Duration) (*FrameTimer, error) {
+	if capacity <= 0 {
+		return nil, errors.New("frame timer capacity must be positive")
+	}
+	surface.timer = newFrameTimer(capacity, refreshPeriod)
+	return surface.timer, nil
+}
+
+func (surface *Surface) DisableFrameTimer() {
+	surface.timer = nil
+}
+
+// FrameTimer returns the surface's frame timer, or nil if it is disabled.
+func (surface *Surface) FrameTimer() *FrameTimer {
+	return surface.timer
+}
+
+func (timer *FrameTimer) record(start time.Time, swapInterval int) {
+	duration := time.Since(start)
+
+	timer.lock.Lock()
+	defer timer.lock.Unlock()
+
+	var interval time.Duration
+	if !timer.lastStart.IsZero() {
+		interval = start.Sub(timer.lastStart)
+	}
+	timer.lastStart = start
+
+	timer.durations[timer.next] = duration
+	timer.intervals[timer.next] = interval
+	timer.swapIntervals[timer.next] = swapInterval
+	timer.next = (timer.next + 1) % len(timer.durations)
+	if timer.count < len(timer.durations) {
+		timer.count++
+	}
+	timer.totalFrames++
+}
+
+// Reset discards every recorded frame.
+func (timer *FrameTimer) Reset() {
+	timer.lock.Lock()
+	defer timer.lock.Unlock()
+
+	timer.next = 0
+	timer.count = 0
+	timer.totalFrames = 0
+	timer.lastStart = time.Time{}
+}
+
+// Snapshot computes statistics over the frames currently recorded.
+func (timer *FrameTimer) Snapshot() FrameStats {
+	timer.lock.Lock()
+	count := timer.count
+	first := (timer.next - count + len(timer.durations)) % len(timer.durations)
+	durations := make([]time.Duration, 0, count)
+	intervals := make([]time.Duration, 0, count)
+	swapIntervals := make([]int, 0, count)
+	for i := 0; i < count; i++ {
+		index := (first + i) % len(timer.durations)
+		durations = append(durations, timer.durations[index])
+		if timer.intervals[index] == 0 {
+			// the first frame has no interval
+			continue
+		}
+		intervals = append(intervals, timer.intervals[index])
+		swapIntervals = append(swapIntervals, timer.swapIntervals[index])
+	}
+	stats := FrameStats{
+		Frames: count,
+		TotalFrames: timer.totalFrames,
+	}
+	refreshPeriod := timer.refreshPeriod
+	timer.lock.Unlock()
+
+	if refreshPeriod > 0 {
+		for i, interval := range(intervals) {
+			swapInterval := swapIntervals[i]
+			if swapInterval < 1 {
+				swapInterval = 1
+			}
+			expected := refreshPeriod * time.Duration(swapInterval)
+			periods := int((interval + expected / 2) / expected)
+			if periods > 1 {
+				stats.DroppedFrames += periods - 1
+			}
+		}
+	}
+
+	stats.Jitter = standardDeviation(intervals)
+	stats.Duration = distribution(durations)
+	stats.Interval = distribution(intervals)
+	return stats
+}
+
+func distribution(values []time.Duration) FrameDistribution {
+	var result FrameDistribution
+	if len(values) == 0 {
+		return result
+	}
+
+	sorted := append([]time.Duration(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var sum time.Duration
+	for _, value := range(sorted) {
+		sum += value
+	}
+
+	result.Min = sorted[0]
+	result.Max = sorted[len(sorted) - 1]
+	result.Mean = sum / time.Duration(len(sorted))
+	result.P50 = percentile(sorted, 50)
+	result.P90 = percentile(sorted, 90)
+	result.P95 = percentile(sorted, 95)
+	result.P99 = percentile(sorted, 99)
+	return result
+}
+
+// percentile expects sorted to be in ascending order and non-empty.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p * len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank - 1]
+}
+
+func standardDeviation(values []time.Duration) time.Duration {
+	if len(values) < 2 {
+		return 0
+	}
+
+	var mean float64
+	for _, value := range(values) {
+		mean += float64(value)
+	}
+	mean /= float64(len(values))
+
+	var variance float64
+	for _, value := range(values) {
+		difference := float64(value) - mean
+		variance += difference * difference
+	}
+	variance /= float64(len(values))
+
+	return time.Duration(math.Sqrt(variance))
+}
@@ -0,0 +1,103 @@
+package egl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFrameTimerSnapshot(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		name string
+		capacity int
+		refreshPeriod time.Duration
+		gaps []time.Duration // between the starts of consecutive frames
+		swapInterval int
+		wantFrames int
+		wantInterval FrameDistribution
+		wantJitter time.Duration
+		wantDropped int
+	}{
+		{
+			name: "steady",
+			capacity: 8,
+			refreshPeriod: 10 * ms,
+			gaps: []time.Duration{10 * ms, 10 * ms, 10 * ms, 10 * ms},
+			swapInterval: 1,
+			wantFrames: 5,
+			wantInterval: FrameDistribution{10 * ms, 10 * ms, 10 * ms, 10 * ms, 10 * ms, 10 * ms, 10 * ms},
+		},
+		{
+			// nearest rank of 10 values: P50 is the 5th, P90 the 9th, P95
+			// and P99 the 10th
+			name: "percentiles",
+			capacity: 16,
+			gaps: []time.Duration{7 * ms, 3 * ms, 10 * ms, 1 * ms, 5 * ms, 9 * ms, 2 * ms, 8 * ms, 4 * ms, 6 * ms},
+			swapInterval: 1,
+			wantFrames: 11,
+			wantInterval: FrameDistribution{1 * ms, 10 * ms, 5500 * time.Microsecond, 5 * ms, 9 * ms, 10 * ms, 10 * ms},
+			wantJitter: 2872281, // sqrt(8.25) ms
+		},
+		{
+			// only the last 4 frames are kept, and the oldest of them still
+			// has its interval
+			name: "wrapped",
+			capacity: 4,
+			refreshPeriod: 10 * ms,
+			gaps: []time.Duration{10 * ms, 10 * ms, 10 * ms, 10 * ms, 20 * ms, 30 * ms},
+			swapInterval: 1,
+			wantFrames: 4,
+			wantInterval: FrameDistribution{10 * ms, 30 * ms, 17500 * time.Microsecond, 10 * ms, 30 * ms, 30 * ms, 30 * ms},
+			wantJitter: 8291561, // sqrt(68.75) ms
+			wantDropped: 3,
+		},
+		{
+			// each frame should take two refresh periods
+			name: "dropped",
+			capacity: 8,
+			refreshPeriod: 10 * ms,
+			gaps: []time.Duration{20 * ms, 20 * ms, 40 * ms, 19 * ms},
+			swapInterval: 2,
+			wantFrames: 5,
+			wantInterval: FrameDistribution{19 * ms, 40 * ms, 24750 * time.Microsecond, 20 * ms, 40 * ms, 40 * ms, 40 * ms},
+			wantJitter: 8814051, // sqrt(77.6875) ms
+			wantDropped: 1,
+		},
+	}
+
+	for _, test := range(tests) {
+		t.Run(test.name, func(t *testing.T) {
+			timer := newFrameTimer(test.capacity, test.refreshPeriod)
+			start := time.Now().Add(-time.Second)
+			timer.record(start, test.swapInterval)
+			for _, gap := range(test.gaps) {
+				start = start.Add(gap)
+				timer.record(start, test.swapInterval)
+			}
+
+			stats := timer.Snapshot()
+			if stats.Frames != test.wantFrames || stats.TotalFrames != uint64(len(test.gaps) + 1) {
+				t.Errorf("got %d frames of %d, want %d of %d", stats.Frames, stats.TotalFrames, test.wantFrames, len(test.gaps) + 1)
+			}
+			if stats.Interval != test.wantInterval {
+				t.Errorf("got intervals %+v, want %+v", stats.Interval, test.wantInterval)
+			}
+			if stats.Jitter != test.wantJitter {
+				t.Errorf("got jitter %v, want %v", stats.Jitter, test.wantJitter)
+			}
+			if stats.DroppedFrames != test.wantDropped {
+				t.Errorf("got %d dropped frames, want %d", stats.DroppedFrames, test.wantDropped)
+			}
+			// durations are measured up to now, so only their order is known
+			if stats.Duration.Min <= 0 || stats.Duration.Min > stats.Duration.Max {
+				t.Errorf("got durations %+v", stats.Duration)
+			}
+
+			timer.Reset()
+			stats = timer.Snapshot()
+			if stats.Frames != 0 || stats.TotalFrames != 0 || stats.Interval != (FrameDistribution{}) {
+				t.Errorf("got %+v after Reset", stats)
+			}
+		})
+	}
+}
@@ -0,0 +1,530 @@
+//go:build ignore
+// +build ignore
+
+/*
+ * gengl writes glfuncs.go, the GLES entry points returned by Context.GL.
+ * Run it with go generate after changing the tables below.
+ */
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strings"
+)
+
+/*
+ * A param is one argument of a Go wrapper. Its kind decides the Go type and
+ * the C arguments it becomes: slices pass a pointer to their first element,
+ * and slices of ids also pass their length as the count before it.
+ */
+type param struct {
+	name string
+	kind string
+}
+
+type function struct {
+	name string // GL name, such as glViewport
+	result string // kind of the return value, empty for none
+	params []param
+	es3 bool // only in GLES 3.0 and later
+}
+
+type kind struct {
+	goType string
+	cParams []string // C declarations, with %s for the param name
+	cArgs []string // arguments to the GL function
+	goArgs []string // Go expressions passed to the trampoline
+	setup string // Go statements run before the call
+	glParams []string // the GL function's declarations, if not cParams
+}
+
+var kinds = map[string]kind{
+	"enum": {"uint32", []string{"unsigned int %s"}, []string{"%s"}, []string{"C.uint(%s)"}, "", nil},
+	"uint": {"uint32", []string{"unsigned int %s"}, []string{"%s"}, []string{"C.uint(%s)"}, "", nil},
+	"int": {"int32", []string{"int %s"}, []string{"%s"}, []string{"C.int(%s)"}, "", nil},
+	"float": {"float32", []string{"float %s"}, []string{"%s"}, []string{"C.float(%s)"}, "", nil},
+	"bool": {"bool", []string{"unsigned char %s"}, []string{"%s"}, []string{"glBoolean(%s)"}, "", nil},
+	// byte offsets into the bound buffer object
+	"offset": {"uintptr", []string{"uintptr_t %s"}, []string{"(void *)%s"}, []string{"C.uintptr_t(%s)"}, "", []string{"const void *%s"}},
+	"string": {
+		"string",
+		[]string{"const char *%s"},
+		[]string{"%s"},
+		[]string{"c_%s"},
+		"\tc_%[1]s := C.CString(%[1]s)\n\tdefer C.free(unsafe.Pointer(c_%[1]s))\n",
+		nil,
+	},
+	"strings": {
+		"[]string",
+		[]string{"int %sCount", "const char **%s"},
+		[]string{"%sCount", "%s", "NULL"},
+		[]string{"C.int(len(%s))", "c_%s"},
+		"\tc_%[1]s := cStrings(%[1]s)\n\tdefer freeCStrings(c_%[1]s, len(%[1]s))\n",
+		[]string{"int %sCount", "const char **%s", "const int *%sLengths"},
+	},
+	// pixels for a width by height rectangle of format and componentType,
+	// checked against the pack or unpack state, so the function must have
+	// params of those names and return error
+	"packPixels": {"[]byte", []string{"void *%s"}, []string{"%s"}, []string{"bytesPointer(%s)"}, pixelsCheck("packStore", false), nil},
+	"unpackPixels": {"[]byte", []string{"const void *%s"}, []string{"%s"}, []string{"bytesPointer(%s)"}, pixelsCheck("unpackStore", false), nil},
+	// as unpackPixels, but nil leaves a new texture's contents undefined
+	"texPixels": {"[]byte", []string{"const void *%s"}, []string{"%s"}, []string{"bytesPointer(%s)"}, pixelsCheck("unpackStore", true), nil},
+	// data for a buffer object, preceded by its size
+	"data": {
+		"[]byte",
+		[]string{"intptr_t %sSize", "const void *%s"},
+		[]string{"%sSize", "%s"},
+		[]string{"C.intptr_t(len(%s))", "bytesPointer(%s)"},
+		"",
+		nil,
+	},
+	"ids": {
+		"[]uint32",
+		[]string{"int %sCount", "unsigned int *%s"},
+		[]string{"%sCount", "%s"},
+		[]string{"C.int(len(%s))", "(*C.uint)(uint32sPointer(%s))"},
+		"",
+		nil,
+	},
+	// the answer to a query, checked against the query's size, so the
+	// function must have a param called name and return error
+	"ints": {"[]int32", []string{"int *%s"}, []string{"%s"}, []string{"(*C.int)(int32sPointer(%s))"}, "\tintsErr := checkInts(%[1]s, name)\n\tif intsErr != nil {\n\t\treturn intsErr\n\t}\n", nil},
+	// a count of vectors or matrices followed by their components, checked
+	// to be whole, so the function must return error
+	"vec4s": {
+		"[]float32",
+		[]string{"int %sCount", "const float *%s"},
+		[]string{"%sCount", "%s"},
+		[]string{"C.int(len(%s) / 4)", "(*C.float)(float32sPointer(%s))"},
+		"\tvaluesErr := checkFloats(%[1]s, 4)\n\tif valuesErr != nil {\n\t\treturn valuesErr\n\t}\n",
+		nil,
+	},
+	"mat4s": {
+		"[]float32",
+		[]string{"int %sCount", "const float *%s"},
+		[]string{"%sCount", "%s"},
+		[]string{"C.int(len(%s) / 16)", "(*C.float)(float32sPointer(%s))"},
+		"\tvaluesErr := checkFloats(%[1]s, 16)\n\tif valuesErr != nil {\n\t\treturn valuesErr\n\t}\n",
+		nil,
+	},
+	// an info log buffer, its size and where the length written goes
+	"log": {
+		"[]byte",
+		[]string{"int %sSize", "int *%sLength", "char *%s"},
+		[]string{"%sSize", "%sLength", "%s"},
+		[]string{"C.int(len(%s))", "&length", "(*C.char)(bytesPointer(%s))"},
+		"\tvar length C.int\n",
+		nil,
+	},
+}
+
+func pixelsCheck(store string, nilAllowed bool) string {
+	return fmt.Sprintf("\tpixelsErr := gl.checkPixels(%%[1]s, %s, %t, width, height, format, componentType)\n\tif pixelsErr != nil {\n\t\treturn pixelsErr\n\t}\n", store, nilAllowed)
+}
+
+// results maps result kinds to their C type, Go type and conversion.
+var results = map[string][3]string{
+	"enum": {"unsigned int", "uint32", "uint32(%s)"},
+	"uint": {"unsigned int", "uint32", "uint32(%s)"},
+	"int": {"int", "int32", "int32(%s)"},
+	"bool": {"unsigned char", "bool", "%s != 0"},
+	"string": {"const char *", "string", "C.GoString(%s)"},
+	// the length an info log call wrote
+	"length": {"void", "int32", "int32(length)"},
+	// nil, for functions whose arguments are checked first
+	"error": {"void", "error", "nil"},
+}
+
+func p(name, kind string) param {
+	return param{name, kind}
+}
+
+var functions = []function{
+	// State and errors
+	{"glGetError", "enum", nil, false},
+	{"glGetString", "string", []param{p("name", "enum")}, false},
+	{"glGetIntegerv", "error", []param{p("name", "enum"), p("data", "ints")}, false},
+	{"glEnable", "", []param{p("capability", "enum")}, false},
+	{"glDisable", "", []param{p("capability", "enum")}, false},
+	{"glPixelStorei", "", []param{p("name", "enum"), p("param", "int")}, false},
+	{"glFlush", "", nil, false},
+	{"glFinish", "", nil, false},
+
+	// Clearing and viewport
+	{"glClearColor", "", []param{p("red", "float"), p("green", "float"), p("blue", "float"), p("alpha", "float")}, false},
+	{"glClear", "", []param{p("mask", "uint")}, false},
+	{"glViewport", "", []param{p("x", "int"), p("y", "int"), p("width", "int"), p("height", "int")}, false},
+	{"glScissor", "", []param{p("x", "int"), p("y", "int"), p("width", "int"), p("height", "int")}, false},
+
+	// Shaders and programs
+	{"glCreateShader", "uint", []param{p("shaderType", "enum")}, false},
+	{"glShaderSource", "", []param{p("shader", "uint"), p("sources", "strings")}, false},
+	{"glCompileShader", "", []param{p("shader", "uint")}, false},
+	{"glGetShaderiv", "error", []param{p("shader", "uint"), p("name", "enum"), p("params", "ints")}, false},
+	{"glGetShaderInfoLog", "length", []param{p("shader", "uint"), p("infoLog", "log")}, false},
+	{"glDeleteShader", "", []param{p("shader", "uint")}, false},
+	{"glCreateProgram", "uint", nil, false},
+	{"glAttachShader", "", []param{p("program", "uint"), p("shader", "uint")}, false},
+	{"glBindAttribLocation", "", []param{p("program", "uint"), p("index", "uint"), p("name", "string")}, false},
+	{"glLinkProgram", "", []param{p("program", "uint")}, false},
+	{"glGetProgramiv", "error", []param{p("program", "uint"), p("name", "enum"), p("params", "ints")}, false},
+	{"glGetProgramInfoLog", "length", []param{p("program", "uint"), p("infoLog", "log")}, false},
+	{"glUseProgram", "", []param{p("program", "uint")}, false},
+	{"glDeleteProgram", "", []param{p("program", "uint")}, false},
+	{"glGetAttribLocation", "int", []param{p("program", "uint"), p("name", "string")}, false},
+	{"glGetUniformLocation", "int", []param{p("program", "uint"), p("name", "string")}, false},
+	{"glUniform1i", "", []param{p("location", "int"), p("x", "int")}, false},
+	{"glUniform1f", "", []param{p("location", "int"), p("x", "float")}, false},
+	{"glUniform2f", "", []param{p("location", "int"), p("x", "float"), p("y", "float")}, false},
+	{"glUniform4f", "", []param{p("location", "int"), p("x", "float"), p("y", "float"), p("z", "float"), p("w", "float")}, false},
+	{"glUniform4fv", "error", []param{p("location", "int"), p("values", "vec4s")}, false},
+	{"glUniformMatrix4fv", "error", []param{p("location", "int"), p("transpose", "bool"), p("values", "mat4s")}, false},
+
+	// Buffers and drawing
+	{"glGenBuffers", "", []param{p("buffers", "ids")}, false},
+	{"glDeleteBuffers", "", []param{p("buffers", "ids")}, false},
+	{"glBindBuffer", "", []param{p("target", "enum"), p("buffer", "uint")}, false},
+	{"glBufferData", "", []param{p("target", "enum"), p("data", "data"), p("usage", "enum")}, false},
+	{"glEnableVertexAttribArray", "", []param{p("index", "uint")}, false},
+	{"glDisableVertexAttribArray", "", []param{p("index", "uint")}, false},
+	{"glVertexAttribPointer", "", []param{p("index", "uint"), p("size", "int"), p("componentType", "enum"), p("normalized", "bool"), p("stride", "int"), p("offset", "offset")}, false},
+	{"glDrawArrays", "", []param{p("mode", "enum"), p("first", "int"), p("count", "int")}, false},
+	{"glDrawElements", "", []param{p("mode", "enum"), p("count", "int"), p("indexType", "enum"), p("offset", "offset")}, false},
+
+	// Textures
+	{"glGenTextures", "", []param{p("textures", "ids")}, false},
+	{"glDeleteTextures", "", []param{p("textures", "ids")}, false},
+	{"glActiveTexture", "", []param{p("unit", "enum")}, false},
+	{"glBindTexture", "", []param{p("target", "enum"), p("texture", "uint")}, false},
+	{"glTexParameteri", "", []param{p("target", "enum"), p("name", "enum"), p("param", "int")}, false},
+	{"glTexImage2D", "error", []param{p("target", "enum"), p("level", "int"), p("internalFormat", "int"), p("width", "int"), p("height", "int"), p("border", "int"), p("format", "enum"), p("componentType", "enum"), p("pixels", "texPixels")}, false},
+	{"glTexSubImage2D", "error", []param{p("target", "enum"), p("level", "int"), p("x", "int"), p("y", "int"), p("width", "int"), p("height", "int"), p("format", "enum"), p("componentType", "enum"), p("pixels", "unpackPixels")}, false},
+	{"glGenerateMipmap", "", []param{p("target", "enum")}, false},
+	{"glTexStorage2D", "", []param{p("target", "enum"), p("levels", "int"), p("internalFormat", "enum"), p("width", "int"), p("height", "int")}, true},
+
+	// Framebuffers and renderbuffers
+	{"glGenFramebuffers", "", []param{p("framebuffers", "ids")}, false},
+	{"glDeleteFramebuffers", "", []param{p("framebuffers", "ids")}, false},
+	{"glBindFramebuffer", "", []param{p("target", "enum"), p("framebuffer", "uint")}, false},
+	{"glFramebufferTexture2D", "", []param{p("target", "enum"), p("attachment", "enum"), p("textureTarget", "enum"), p("texture", "uint"), p("level", "int")}, false},
+	{"glFramebufferRenderbuffer", "", []param{p("target", "enum"), p("attachment", "enum"), p("renderbufferTarget", "enum"), p("renderbuffer", "uint")}, false},
+	{"glCheckFramebufferStatus", "enum", []param{p("target", "enum")}, false},
+	{"glGenRenderbuffers", "", []param{p("renderbuffers", "ids")}, false},
+	{"glDeleteRenderbuffers", "", []param{p("renderbuffers", "ids")}, false},
+	{"glBindRenderbuffer", "", []param{p("target", "enum"), p("renderbuffer", "uint")}, false},
+	{"glRenderbufferStorage", "", []param{p("target", "enum"), p("internalFormat", "enum"), p("width", "int"), p("height", "int")}, false},
+	{"glRenderbufferStorageMultisample", "", []param{p("target", "enum"), p("samples", "int"), p("internalFormat", "enum"), p("width", "int"), p("height", "int")}, true},
+	{"glBlitFramebuffer", "", []param{p("srcX0", "int"), p("srcY0", "int"), p("srcX1", "int"), p("srcY1", "int"), p("dstX0", "int"), p("dstY0", "int"), p("dstX1", "int"), p("dstY1", "int"), p("mask", "uint"), p("filter", "enum")}, true},
+	{"glInvalidateFramebuffer", "", []param{p("target", "enum"), p("attachments", "ids")}, true},
+
+	// Readback
+	{"glReadPixels", "error", []param{p("x", "int"), p("y", "int"), p("width", "int"), p("height", "int"), p("format", "enum"), p("componentType", "enum"), p("pixels", "packPixels")}, false},
+	{"glReadBuffer", "", []param{p("source", "enum")}, true},
+}
+
+type enum struct {
+	name string
+	value uint32
+}
+
+// enums are grouped like constants.go, each under its enumGroups comment
+var enums = [][]enum{
+	{
+		{"GLDepthBufferBit", 0x0100},
+		{"GLStencilBufferBit", 0x0400},
+		{"GLColorBufferBit", 0x4000},
+	},
+	{
+		{"GLNoError", 0},
+		{"GLInvalidEnum", 0x0500},
+		{"GLInvalidValue", 0x0501},
+		{"GLInvalidOperation", 0x0502},
+		{"GLOutOfMemory", 0x0505},
+		{"GLInvalidFramebufferOperation", 0x0506},
+	},
+	{
+		{"GLVendor", 0x1F00},
+		{"GLRenderer", 0x1F01},
+		{"GLVersion", 0x1F02},
+		{"GLExtensions", 0x1F03},
+		{"GLShadingLanguageVersion", 0x8B8C},
+		{"GLMaxTextureSize", 0x0D33},
+		{"GLMaxRenderbufferSize", 0x84E8},
+		{"GLViewport", 0x0BA2},
+	},
+	{
+		{"GLBlend", 0x0BE2},
+		{"GLCullFace", 0x0B44},
+		{"GLDepthTest", 0x0B71},
+		{"GLScissorTest", 0x0C11},
+		{"GLDither", 0x0BD0},
+		{"GLUnpackRowLength", 0x0CF2},
+		{"GLUnpackSkipRows", 0x0CF3},
+		{"GLUnpackSkipPixels", 0x0CF4},
+		{"GLUnpackAlignment", 0x0CF5},
+		{"GLPackRowLength", 0x0D02},
+		{"GLPackSkipRows", 0x0D03},
+		{"GLPackSkipPixels", 0x0D04},
+		{"GLPackAlignment", 0x0D05},
+	},
+	{
+		{"GLPoints", 0x0000},
+		{"GLLines", 0x0001},
+		{"GLTriangles", 0x0004},
+		{"GLTriangleStrip", 0x0005},
+		{"GLTriangleFan", 0x0006},
+	},
+	{
+		{"GLByte", 0x1400},
+		{"GLUnsignedByte", 0x1401},
+		{"GLShort", 0x1402},
+		{"GLUnsignedShort", 0x1403},
+		{"GLInt", 0x1404},
+		{"GLUnsignedInt", 0x1405},
+		{"GLFloat", 0x1406},
+		{"GLHalfFloat", 0x140B},
+		{"GLHalfFloatOES", 0x8D61},
+		{"GLUnsignedShort4444", 0x8033},
+		{"GLUnsignedShort5551", 0x8034},
+		{"GLUnsignedShort565", 0x8363},
+		{"GLUnsignedInt2101010Rev", 0x8368},
+		{"GLUnsignedInt10F11F11FRev", 0x8C3B},
+		{"GLUnsignedInt5999Rev", 0x8C3E},
+		{"GLUnsignedInt248", 0x84FA},
+		{"GLFloat32UnsignedInt248Rev", 0x8DAD},
+	},
+	{
+		{"GLDepthComponent", 0x1902},
+		{"GLRed", 0x1903},
+		{"GLAlpha", 0x1906},
+		{"GLRGB", 0x1907},
+		{"GLRGBA", 0x1908},
+		{"GLLuminance", 0x1909},
+		{"GLLuminanceAlpha", 0x190A},
+		{"GLRG", 0x8227},
+		{"GLRGInteger", 0x8228},
+		{"GLRedInteger", 0x8D94},
+		{"GLRGBInteger", 0x8D98},
+		{"GLRGBAInteger", 0x8D99},
+		{"GLDepthStencil", 0x84F9},
+		{"GLBGRA", 0x80E1},
+		{"GLRGBA4", 0x8056},
+		{"GLRGB565", 0x8D62},
+		{"GLRGBA8", 0x8058},
+		{"GLRGB10A2", 0x8059},
+		{"GLRGBA16F", 0x881A},
+		{"GLDepthComponent16", 0x81A5},
+		{"GLDepthComponent24", 0x81A6},
+		{"GLDepth24Stencil8", 0x88F0},
+	},
+	{
+		{"GLArrayBuffer", 0x8892},
+		{"GLElementArrayBuffer", 0x8893},
+		{"GLStreamDraw", 0x88E0},
+		{"GLStaticDraw", 0x88E4},
+		{"GLDynamicDraw", 0x88E8},
+		{"GLPixelPackBuffer", 0x88EB},
+		{"GLPixelUnpackBuffer", 0x88EC},
+		{"GLPixelPackBufferBinding", 0x88ED},
+		{"GLPixelUnpackBufferBinding", 0x88EF},
+	},
+	{
+		{"GLTexture2D", 0x0DE1},
+		{"GLTexture0", 0x84C0},
+		{"GLTextureMagFilter", 0x2800},
+		{"GLTextureMinFilter", 0x2801},
+		{"GLTextureWrapS", 0x2802},
+		{"GLTextureWrapT", 0x2803},
+		{"GLNearest", 0x2600},
+		{"GLLinear", 0x2601},
+		{"GLLinearMipmapLinear", 0x2703},
+		{"GLRepeat", 0x2901},
+		{"GLClampToEdge", 0x812F},
+	},
+	{
+		{"GLFragmentShader", 0x8B30},
+		{"GLVertexShader", 0x8B31},
+		{"GLCompileStatus", 0x8B81},
+		{"GLLinkStatus", 0x8B82},
+		{"GLInfoLogLength", 0x8B84},
+	},
+	{
+		{"GLFramebuffer", 0x8D40},
+		{"GLReadFramebuffer", 0x8CA8},
+		{"GLDrawFramebuffer", 0x8CA9},
+		{"GLRenderbuffer", 0x8D41},
+		{"GLColorAttachment0", 0x8CE0},
+		{"GLDepthAttachment", 0x8D00},
+		{"GLStencilAttachment", 0x8D20},
+		{"GLFramebufferComplete", 0x8CD5},
+		{"GLBack", 0x0405},
+	},
+}
+
+var enumGroups = []string{
+	"Clear masks",
+	"Errors",
+	"Queries",
+	"Capabilities and pixel storage",
+	"Primitives",
+	"Component types",
+	"Formats",
+	"Buffer objects",
+	"Textures",
+	"Shaders",
+	"Framebuffers",
+}
+
+// goName turns glTexImage2D into TexImage2D.
+func goName(name string) string {
+	return strings.TrimPrefix(name, "gl")
+}
+
+func expand(templates []string, name string) []string {
+	var expanded []string
+	for _, template := range(templates) {
+		expanded = append(expanded, strings.Replace(template, "%s", name, -1))
+	}
+	return expanded
+}
+
+func writeTrampoline(out *bytes.Buffer, f function) {
+	resultType := "void"
+	if f.result != "" {
+		resultType = results[f.result][0]
+	}
+	var cParams, cArgs, glParams []string
+	for _, param := range(f.params) {
+		k := kinds[param.kind]
+		cParams = append(cParams, expand(k.cParams, param.name)...)
+		cArgs = append(cArgs, expand(k.cArgs, param.name)...)
+		if k.glParams != nil {
+			glParams = append(glParams, expand(k.glParams, param.name)...)
+		} else {
+			glParams = append(glParams, expand(k.cParams, param.name)...)
+		}
+	}
+
+	typeParams := "void"
+	if len(glParams) > 0 {
+		typeParams = strings.Join(glParams, ", ")
+	}
+	fmt.Fprintf(out, "typedef %s (*%sFunc)(%s);\n", resultType, f.name, typeParams)
+	fmt.Fprintf(out, "static %s call_%s(%s) {\n", resultType, f.name, strings.Join(append([]string{"void *function"}, cParams...), ", "))
+	call := fmt.Sprintf("((%sFunc)function)(%s)", f.name, strings.Join(cArgs, ", "))
+	if resultType == "void" {
+		fmt.Fprintf(out, "\t%s;\n", call)
+	} else {
+		fmt.Fprintf(out, "\treturn %s;\n", call)
+	}
+	fmt.Fprintf(out, "}\n\n")
+}
+
+// cName names the C copy of a Go argument, such as cSources for sources.
+func cName(name string) string {
+	return "c" + strings.ToUpper(name[:1]) + name[1:]
+}
+
+func writeWrapper(out *bytes.Buffer, f function) {
+	var goParams, goArgs []string
+	var setup string
+	for _, param := range(f.params) {
+		k := kinds[param.kind]
+		goParams = append(goParams, param.name + " " + k.goType)
+		for _, arg := range(expand(k.goArgs, param.name)) {
+			goArgs = append(goArgs, strings.Replace(arg, "c_" + param.name, cName(param.name), -1))
+		}
+		paramSetup := k.setup
+		if strings.Contains(paramSetup, "%") {
+			paramSetup = fmt.Sprintf(paramSetup, param.name)
+		}
+		setup += strings.Replace(paramSetup, "c_" + param.name, cName(param.name), -1)
+	}
+
+	name := goName(f.name)
+	var goResult string
+	if f.result != "" {
+		goResult = " " + results[f.result][1]
+	}
+	receiver := "GL"
+	if f.es3 {
+		receiver = "GL3"
+	}
+	fmt.Fprintf(out, "// %s calls %s.\n", name, f.name)
+	fmt.Fprintf(out, "func (gl *%s) %s(%s)%s {\n", receiver, name, strings.Join(goParams, ", "), goResult)
+	out.WriteString(setup)
+	call := fmt.Sprintf("C.call_%s(%s)", f.name, strings.Join(append([]string{"gl." + f.name}, goArgs...), ", "))
+	switch f.result {
+		case "":
+			fmt.Fprintf(out, "\t%s\n", call)
+		case "length", "error":
+			fmt.Fprintf(out, "\t%s\n", call)
+			fmt.Fprintf(out, "\treturn %s\n", results[f.result][2])
+		default:
+			fmt.Fprintf(out, "\treturn %s\n", fmt.Sprintf(results[f.result][2], call))
+	}
+	fmt.Fprintf(out, "}\n\n")
+}
+
+func main() {
+	var out bytes.Buffer
+	out.WriteString("// Code generated by gengl.go; DO NOT EDIT.\n\n")
+	out.WriteString("package egl\n\n")
+	out.WriteString("/*\n#cgo pkg-config: egl\n\n#include <stdint.h>\n#include <stdlib.h>\n\n")
+	out.WriteString("// GL types are spelled out so this file doesn't need the GLES headers.\n\n")
+	for _, f := range(functions) {
+		writeTrampoline(&out, f)
+	}
+	out.WriteString("*/\nimport \"C\"\n\nimport (\n\t\"unsafe\"\n)\n\n")
+
+	for i, group := range(enums) {
+		fmt.Fprintf(&out, "// %s\nconst (\n", enumGroups[i])
+		for _, e := range(group) {
+			fmt.Fprintf(&out, "\t%s = 0x%04X\n", e.name, e.value)
+		}
+		out.WriteString(")\n\n")
+	}
+
+	out.WriteString("// glFuncs holds the entry points resolved for one context.\n")
+	out.WriteString("type glFuncs struct {\n")
+	for _, f := range(functions) {
+		fmt.Fprintf(&out, "\t%s unsafe.Pointer\n", f.name)
+	}
+	out.WriteString("}\n\n")
+
+	out.WriteString("/*\n * resolve looks up every entry point, returning the names of GLES 2\n * functions that could not be found. Missing GLES 3 functions are only\n * reported by es3Missing.\n */\n")
+	out.WriteString("func (funcs *glFuncs) resolve() (missing []string) {\n")
+	for _, f := range(functions) {
+		fmt.Fprintf(&out, "\tfuncs.%[1]s = procAddress(%[1]q)\n", f.name)
+	}
+	for _, f := range(functions) {
+		if !f.es3 {
+			fmt.Fprintf(&out, "\tif funcs.%[1]s == nil {\n\t\tmissing = append(missing, %[1]q)\n\t}\n", f.name)
+		}
+	}
+	out.WriteString("\treturn missing\n}\n\n")
+
+	out.WriteString("func (funcs *glFuncs) es3Missing() bool {\n\treturn ")
+	var checks []string
+	for _, f := range(functions) {
+		if f.es3 {
+			checks = append(checks, "funcs." + f.name + " == nil")
+		}
+	}
+	out.WriteString(strings.Join(checks, " ||\n\t\t"))
+	out.WriteString("\n}\n\n")
+
+	for _, f := range(functions) {
+		writeWrapper(&out, f)
+	}
+
+	// no trailing blank line
+	source := bytes.TrimRight(out.Bytes(), "\n")
+	writeErr := ioutil.WriteFile("glfuncs.go", append(source, '\n'), 0644)
+	if writeErr != nil {
+		log.Fatal(writeErr)
+	}
+}
@@ -0,0 +1,279 @@
+package egl
+
+/*
+#cgo pkg-config: egl
+
+#include <EGL/egl.h>
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unsafe"
+)
+
+//go:generate go run gengl.go
+
+/*
+ * GL is a small set of GLES 2 and 3 entry points, resolved for one context:
+ * enough to clear, draw with shaders and textures into framebuffers and read
+ * the results back without other GL bindings. Methods call whichever context
+ * is current on the calling thread, which should be the one GL came from.
+ * Slices stand in for GL's pointers, and for its counts where GL takes one.
+ * Methods taking pixels or integer queries check the slice is long enough
+ * first, and those taking vectors or matrices that it holds whole ones,
+ * returning an error otherwise. The GLES 3 methods are on GL3.
+ */
+type GL struct {
+	glFuncs
+	Context *Context
+	gl3 *GL3 // nil unless the context is GLES 3
+	pixelStore bool // row length, skips and pixel buffers can be queried
+}
+
+// GL3 adds the GLES 3 entry points to GL. Only GLES 3 contexts have one.
+type GL3 struct {
+	*GL
+}
+
+/*
+ * GL returns the context's entry points, resolving them through
+ * eglGetProcAddress the first time. The context must be current on the
+ * calling thread then, so its GLES version can be queried. Core functions
+ * can only be resolved that way with EGL 1.5 or
+ * EGL_KHR_get_all_proc_addresses, which also make their addresses the same
+ * for every context, so they are shared between contexts.
+ */
+func (context *Context) GL() (*GL, error) {
+	context.glLock.Lock()
+	defer context.glLock.Unlock()
+
+	if context.gl != nil {
+		return context.gl, nil
+	}
+	if C.eglGetCurrentContext() != context.eglContext {
+		return nil, errors.New("context must be current to load its GL functions")
+	}
+	if !context.Display.resolvesCoreGL() {
+		return nil, errors.New("loading GL functions requires EGL 1.5 or EGL_KHR_get_all_proc_addresses")
+	}
+
+	gl := new(GL)
+	gl.Context = context
+	missing := gl.resolve()
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("eglGetProcAddress could not resolve %s", strings.Join(missing, ", "))
+	}
+
+	// desktop GL versions don't match, and have no GL3
+	var major, minor int
+	version := gl.GetString(GLVersion)
+	fmt.Sscanf(version, "OpenGL ES %d.%d", &major, &minor)
+	if major >= 3 && !gl.es3Missing() {
+		gl.gl3 = &GL3{gl}
+	}
+	gl.pixelStore = major >= 3 || !strings.HasPrefix(version, "OpenGL ES")
+
+	context.gl = gl
+	return gl, nil
+}
+
+// ES3 reports whether the context is GLES 3, so GL3 will succeed.
+func (gl *GL) ES3() bool {
+	return gl.gl3 != nil
+}
+
+// GL3 returns the GLES 3 entry points, or an error on GLES 2 contexts.
+func (gl *GL) GL3() (*GL3, error) {
+	if gl.gl3 == nil {
+		return nil, errors.New("GLES 3 entry points require a GLES 3 context")
+	}
+	return gl.gl3, nil
+}
+
+// The pixel storage parameters for reading and for uploading pixels.
+type pixelStore struct {
+	alignment, rowLength, skipRows, skipPixels, bufferBinding uint32
+}
+
+var packStore = pixelStore{GLPackAlignment, GLPackRowLength, GLPackSkipRows, GLPackSkipPixels, GLPixelPackBufferBinding}
+var unpackStore = pixelStore{GLUnpackAlignment, GLUnpackRowLength, GLUnpackSkipRows, GLUnpackSkipPixels, GLPixelUnpackBufferBinding}
+
+// pixelBytes returns the size of one pixel of format and componentType.
+func pixelBytes(format, componentType uint32) (int, error) {
+	switch componentType {
+		case GLUnsignedShort565, GLUnsignedShort4444, GLUnsignedShort5551:
+			return 2, nil
+		case GLUnsignedInt2101010Rev, GLUnsignedInt10F11F11FRev, GLUnsignedInt5999Rev, GLUnsignedInt248:
+			return 4, nil
+		case GLFloat32UnsignedInt248Rev:
+			return 8, nil
+	}
+
+	var componentSize int
+	switch componentType {
+		case GLByte, GLUnsignedByte:
+			componentSize = 1
+		case GLShort, GLUnsignedShort, GLHalfFloat, GLHalfFloatOES:
+			componentSize = 2
+		case GLInt, GLUnsignedInt, GLFloat:
+			componentSize = 4
+		default:
+			return 0, fmt.Errorf("unknown component type 0x%X", componentType)
+	}
+	switch format {
+		case GLAlpha, GLLuminance, GLRed, GLRedInteger, GLDepthComponent:
+			return componentSize, nil
+		case GLLuminanceAlpha, GLRG, GLRGInteger, GLDepthStencil:
+			return componentSize * 2, nil
+		case GLRGB, GLRGBInteger:
+			return componentSize * 3, nil
+		case GLRGBA, GLRGBAInteger, GLBGRA:
+			return componentSize * 4, nil
+	}
+	return 0, fmt.Errorf("unknown pixel format 0x%X", format)
+}
+
+/*
+ * checkPixels returns an error unless pixels holds a width by height
+ * rectangle of format and componentType, laid out as store's alignment,
+ * row length and skips say. Pixel buffer objects aren't supported, since
+ * pixels would have to be an offset into them.
+ */
+func (gl *GL) checkPixels(pixels []byte, store pixelStore, nilAllowed bool, width, height int32, format, componentType uint32) error {
+	if width < 0 || height < 0 {
+		return errors.New("width and height must not be negative")
+	}
+
+	var alignment, rowLength, skipRows, skipPixels, buffer [1]int32
+	gl.GetIntegerv(store.alignment, alignment[:])
+	if gl.pixelStore {
+		gl.GetIntegerv(store.bufferBinding, buffer[:])
+		gl.GetIntegerv(store.rowLength, rowLength[:])
+		gl.GetIntegerv(store.skipRows, skipRows[:])
+		gl.GetIntegerv(store.skipPixels, skipPixels[:])
+	}
+	if buffer[0] != 0 {
+		return errors.New("pixel buffer objects are not supported, unbind them first")
+	}
+	if (pixels == nil && nilAllowed) || width == 0 || height == 0 {
+		return nil
+	}
+
+	bytesPerPixel, formatErr := pixelBytes(format, componentType)
+	if formatErr != nil {
+		return formatErr
+	}
+	rowPixels := int(width)
+	if rowLength[0] > 0 {
+		rowPixels = int(rowLength[0])
+	}
+	stride := rowPixels * bytesPerPixel
+	if alignment[0] > 1 {
+		stride = (stride + int(alignment[0]) - 1) / int(alignment[0]) * int(alignment[0])
+	}
+	needed := (int(skipRows[0]) + int(height) - 1) * stride + (int(skipPixels[0]) + int(width)) * bytesPerPixel
+	if len(pixels) < needed {
+		return fmt.Errorf("%dx%d pixels need %d bytes, the slice has %d", width, height, needed, len(pixels))
+	}
+	return nil
+}
+
+// intCounts holds the queries answering with more than one integer.
+var intCounts = map[uint32]int{
+	GLViewport: 4,
+}
+
+// checkInts returns an error unless values has room for the answer to name.
+func checkInts(values []int32, name uint32) error {
+	count, ok := intCounts[name]
+	if !ok {
+		count = 1
+	}
+	if len(values) < count {
+		return fmt.Errorf("query 0x%X answers with %d integers, the slice has %d", name, count, len(values))
+	}
+	return nil
+}
+
+// checkFloats returns an error unless values holds whole vectors or matrices.
+func checkFloats(values []float32, size int) error {
+	if len(values) % size != 0 {
+		return fmt.Errorf("%d floats are not a whole number of %d component values", len(values), size)
+	}
+	return nil
+}
+
+// CheckError returns an error naming operation if glGetError reports one.
+func (gl *GL) CheckError(operation string) error {
+	glErr := gl.GetError()
+	if glErr != GLNoError {
+		return fmt.Errorf("%s failed with GL error 0x%X", operation, glErr)
+	}
+	return nil
+}
+
+func glBoolean(value bool) C.uchar {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+// The pointer helpers return nil for empty slices, which GL accepts.
+
+func bytesPointer(values []byte) unsafe.Pointer {
+	if len(values) == 0 {
+		return nil
+	}
+	return unsafe.Pointer(&values[0])
+}
+
+func uint32sPointer(values []uint32) unsafe.Pointer {
+	if len(values) == 0 {
+		return nil
+	}
+	return unsafe.Pointer(&values[0])
+}
+
+func int32sPointer(values []int32) unsafe.Pointer {
+	if len(values) == 0 {
+		return nil
+	}
+	return unsafe.Pointer(&values[0])
+}
+
+func float32sPointer(values []float32) unsafe.Pointer {
+	if len(values) == 0 {
+		return nil
+	}
+	return unsafe.Pointer(&values[0])
+}
+
+// cStrings copies values into a C array of C strings, for glShaderSource.
+func cStrings(values []string) **C.char {
+	if len(values) == 0 {
+		return nil
+	}
+	pointerSize := C.size_t(unsafe.Sizeof((*C.char)(nil)))
+	memory := C.malloc(C.size_t(len(values)) * pointerSize)
+	pointers := (*[1 << 28]*C.char)(memory)[:len(values):len(values)]
+	for i, value := range(values) {
+		pointers[i] = C.CString(value)
+	}
+	return (**C.char)(memory)
+}
+
+func freeCStrings(array **C.char, count int) {
+	if array == nil {
+		return
+	}
+	pointers := (*[1 << 28]*C.char)(unsafe.Pointer(array))[:count:count]
+	for _, pointer := range(pointers) {
+		C.free(unsafe.Pointer(pointer))
+	}
+	C.free(unsafe.Pointer(array))
+}
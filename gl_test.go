@@ -0,0 +1,115 @@
+package egl
+
+import (
+	"runtime"
+	"testing"
+)
+
+// currentTestGL makes a GLES context current on a surfaceless display.
+func currentTestGL(t *testing.T) *GL {
+	runtime.LockOSThread()
+	t.Cleanup(runtime.UnlockOSThread)
+
+	display := openTestSurfacelessDisplay(t)
+	BindAPI(OpenGLESAPI)
+	config := chooseTestConfig(t, display, PbufferBit)
+	context, contextErr := display.CreateContext(config, nil, []Attrib{ContextClientVersion, 2, None})
+	if contextErr != nil {
+		t.Skip(contextErr)
+	}
+	t.Cleanup(func() { context.Destroy() })
+	makeErr := context.MakeCurrent(nil, nil)
+	if makeErr != nil {
+		t.Skip(makeErr)
+	}
+	t.Cleanup(func() { display.ReleaseCurrentContext() })
+
+	gl, glErr := context.GL()
+	if glErr != nil {
+		t.Fatal(glErr)
+	}
+	return gl
+}
+
+func TestGLPixelsLength(t *testing.T) {
+	gl := currentTestGL(t)
+
+	var texture [1]uint32
+	gl.GenTextures(texture[:])
+	defer gl.DeleteTextures(texture[:])
+	gl.BindTexture(GLTexture2D, texture[0])
+
+	nilErr := gl.TexImage2D(GLTexture2D, 0, GLRGBA, 8, 4, 0, GLRGBA, GLUnsignedByte, nil)
+	if nilErr != nil {
+		t.Error("TexImage2D rejected nil pixels:", nilErr)
+	}
+	shortErr := gl.TexSubImage2D(GLTexture2D, 0, 0, 0, 8, 4, GLRGBA, GLUnsignedByte, make([]byte, 127))
+	if shortErr == nil {
+		t.Error("TexSubImage2D accepted a short slice")
+	}
+
+	var framebuffer [1]uint32
+	gl.GenFramebuffers(framebuffer[:])
+	defer gl.DeleteFramebuffers(framebuffer[:])
+	gl.BindFramebuffer(GLFramebuffer, framebuffer[0])
+	gl.FramebufferTexture2D(GLFramebuffer, GLColorAttachment0, GLTexture2D, texture[0], 0)
+
+	readErr := gl.ReadPixels(0, 0, 8, 4, GLRGBA, GLUnsignedByte, nil)
+	if readErr == nil {
+		t.Error("ReadPixels accepted nil pixels")
+	}
+	// rows of 3 pixels are padded from 9 to 12 bytes
+	gl.PixelStorei(GLPackAlignment, 4)
+	paddedErr := gl.checkPixels(make([]byte, 20), packStore, false, 3, 2, GLRGB, GLUnsignedByte)
+	if paddedErr == nil {
+		t.Error("checkPixels ignored the pack alignment")
+	}
+	paddedErr = gl.checkPixels(make([]byte, 21), packStore, false, 3, 2, GLRGB, GLUnsignedByte)
+	if paddedErr != nil {
+		t.Error(paddedErr)
+	}
+
+	readErr = gl.ReadPixels(0, 0, 8, 4, GLRGBA, GLUnsignedByte, make([]byte, 128))
+	if readErr != nil {
+		t.Error(readErr)
+	}
+	checkErr := gl.CheckError("reading pixels")
+	if checkErr != nil {
+		t.Error(checkErr)
+	}
+}
+
+func TestGL3(t *testing.T) {
+	gl := currentTestGL(t)
+	gl3, gl3Err := gl.GL3()
+	if gl.ES3() != (gl3Err == nil) || (gl3 != nil) != gl.ES3() {
+		t.Errorf("ES3 is %t but GL3 returned %v, %v", gl.ES3(), gl3, gl3Err)
+	}
+}
+
+func TestGLQueryLength(t *testing.T) {
+	gl := currentTestGL(t)
+
+	emptyErr := gl.GetIntegerv(GLPackAlignment, nil)
+	if emptyErr == nil {
+		t.Error("GetIntegerv accepted an empty slice")
+	}
+	var viewport [4]int32
+	shortErr := gl.GetIntegerv(GLViewport, viewport[:3])
+	if shortErr == nil {
+		t.Error("GetIntegerv accepted 3 integers for GL_VIEWPORT")
+	}
+	viewportErr := gl.GetIntegerv(GLViewport, viewport[:])
+	if viewportErr != nil {
+		t.Error(viewportErr)
+	}
+
+	vectorErr := gl.Uniform4fv(0, make([]float32, 6))
+	if vectorErr == nil {
+		t.Error("Uniform4fv accepted a partial vector")
+	}
+	matrixErr := gl.UniformMatrix4fv(0, false, make([]float32, 20))
+	if matrixErr == nil {
+		t.Error("UniformMatrix4fv accepted a partial matrix")
+	}
+}
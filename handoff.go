@@ -0,0 +1,91 @@
+package egl
+
+import (
+	"sync"
+)
+
+/*
+ * FrameHandoff passes frames from a producer context to a consumer context
+ * on another thread, where the two share objects through CreateContext's
+ * shareContext. The producer calls Publish after drawing each frame and the
+ * consumer calls Acquire before reading it; the consumer's GPU waits for the
+ * producer's commands to finish while neither thread blocks.
+ */
+type FrameHandoff struct {
+	Display *Display
+
+	lock sync.Mutex
+	fence *Sync // fence after the newest unconsumed frame
+}
+
+func NewFrameHandoff(display *Display) *FrameHandoff {
+	handoff := new(FrameHandoff)
+	handoff.Display = display
+	return handoff
+}
+
+/*
+ * Publish fences the frame just drawn by the producer context current on
+ * the calling thread and flushes it, so the consumer can wait for it. An
+ * earlier frame the consumer never acquired is superseded.
+ */
+func (handoff *FrameHandoff) Publish() error {
+	fence, fenceErr := handoff.Display.CreateFenceSync()
+	if fenceErr != nil {
+		return fenceErr
+	}
+	// a zero timeout wait only flushes, since the consumer's server wait
+	// can't flush the producer's commands itself
+	_, flushErr := fence.waitSlice(0)
+	if flushErr != nil {
+		fence.Destroy()
+		return flushErr
+	}
+
+	handoff.lock.Lock()
+	old := handoff.fence
+	handoff.fence = fence
+	handoff.lock.Unlock()
+
+	if old != nil {
+		return old.Destroy()
+	}
+	return nil
+}
+
+/*
+ * Acquire makes consumer, which must be current on the calling thread,
+ * wait on the GPU for the newest published frame. It reports false if no
+ * frame was published since the last Acquire.
+ */
+func (handoff *FrameHandoff) Acquire(consumer *Context) (bool, error) {
+	handoff.lock.Lock()
+	fence := handoff.fence
+	handoff.fence = nil
+	handoff.lock.Unlock()
+
+	if fence == nil {
+		return false, nil
+	}
+	// the fence is only freed once the wait no longer needs it
+	defer fence.Destroy()
+
+	waitErr := consumer.WaitSync(fence)
+	if waitErr != nil {
+		return false, waitErr
+	}
+	return true, nil
+}
+
+// Destroy frees any unconsumed fence.
+func (handoff *FrameHandoff) Destroy() error {
+	handoff.lock.Lock()
+	fence := handoff.fence
+	handoff.fence = nil
+	handoff.lock.Unlock()
+
+	if fence == nil {
+		return nil
+	}
+	return fence.Destroy()
+}
@@ -0,0 +1,257 @@
+package imageproc
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// the most a GPU result may differ from the reference, per component
+const tolerance = 1
+
+/*
+ * newTestProcessor creates a processor, skipping the test when no EGL
+ * display or GLES 2 context is available.
+ */
+func newTestProcessor(tb testing.TB) *Processor {
+	processor, processorErr := NewProcessor()
+	if processorErr != nil {
+		tb.Skip("no EGL display:", processorErr)
+	}
+	tb.Cleanup(func() { processor.Close() })
+	return processor
+}
+
+// newTestImage returns gradients with varying alpha and some hard edges.
+func newTestImage(width, height int) *image.NRGBA {
+	nrgba := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.NRGBA{
+				R: uint8(x * 255 / (width - 1)),
+				G: uint8(y * 255 / (height - 1)),
+				B: uint8((x * 7 + y * 13) * 255 / (width * 7 + height * 13)),
+				A: uint8(255 - (x + y) * 191 / (width + height)),
+			}
+			if (x / 8 + y / 8) % 2 == 0 {
+				c.B = 255 - c.B
+			}
+			nrgba.SetNRGBA(x, y, c)
+		}
+	}
+	return nrgba
+}
+
+func testPixels(tb testing.TB, img image.Image) ([]byte, int, image.Rectangle) {
+	switch img := img.(type) {
+		case *image.RGBA:
+			return img.Pix, img.Stride, img.Rect
+		case *image.NRGBA:
+			return img.Pix, img.Stride, img.Rect
+		case *image.Gray:
+			return img.Pix, img.Stride, img.Rect
+	}
+	tb.Fatalf("unexpected image type %T", img)
+	return nil, 0, image.Rectangle{}
+}
+
+// compareImages fails the test if got and want differ by more than tolerance.
+func compareImages(tb testing.TB, got, want image.Image) {
+	tb.Helper()
+	if fmt.Sprintf("%T", got) != fmt.Sprintf("%T", want) {
+		tb.Fatalf("got %T, want %T", got, want)
+	}
+	gotPix, gotStride, gotRect := testPixels(tb, got)
+	wantPix, wantStride, wantRect := testPixels(tb, want)
+	if gotRect != wantRect {
+		tb.Fatalf("got bounds %v, want %v", gotRect, wantRect)
+	}
+
+	rowBytes := len(wantPix) / wantRect.Dy()
+	if wantStride < rowBytes {
+		rowBytes = wantStride
+	}
+	worst, reported := 0, 0
+	for y := 0; y < wantRect.Dy(); y++ {
+		gotRow := gotPix[y * gotStride:][:rowBytes]
+		wantRow := wantPix[y * wantStride:][:rowBytes]
+		for i := range(wantRow) {
+			difference := int(gotRow[i]) - int(wantRow[i])
+			if difference < 0 {
+				difference = -difference
+			}
+			if difference > worst {
+				worst = difference
+			}
+			if difference > tolerance && reported < 8 {
+				tb.Errorf("byte %d of row %d is %d, want %d", i, y, gotRow[i], wantRow[i])
+				reported++
+			}
+		}
+	}
+	if worst > tolerance {
+		tb.Errorf("largest difference is %d, tolerance is %d", worst, tolerance)
+	}
+}
+
+/*
+ * Each pass can round a component differently from the reference, and
+ * errors in the horizontal pass carry into the vertical one, so the
+ * vertical pass is checked against the reference applied to the GPU's
+ * horizontal result. Resizing only the width runs the horizontal pass
+ * alone, and ReferenceResize from there only the vertical one.
+ */
+func TestResize(t *testing.T) {
+	processor := newTestProcessor(t)
+	src := newTestImage(64, 48)
+	bounds := src.Bounds()
+
+	sizes := []image.Point{{32, 24}, {23, 41}, {64, 48}, {100, 70}, {64, 70}}
+	for _, filter := range([]Filter{Bilinear, Lanczos3}) {
+		for _, size := range(sizes) {
+			t.Run(fmt.Sprintf("filter%d/%dx%d", filter, size.X, size.Y), func(t *testing.T) {
+				horizontal, horizontalErr := processor.Resize(src, size.X, bounds.Dy(), filter)
+				if horizontalErr != nil {
+					t.Fatal(horizontalErr)
+				}
+				want, wantErr := ReferenceResize(src, size.X, bounds.Dy(), filter)
+				if wantErr != nil {
+					t.Fatal(wantErr)
+				}
+				compareImages(t, horizontal, want)
+
+				got, gotErr := processor.Resize(src, size.X, size.Y, filter)
+				if gotErr != nil {
+					t.Fatal(gotErr)
+				}
+				want, wantErr = ReferenceResize(horizontal, size.X, size.Y, filter)
+				if wantErr != nil {
+					t.Fatal(wantErr)
+				}
+				compareImages(t, got, want)
+			})
+		}
+	}
+}
+
+// blurPass runs only the horizontal or only the vertical pass of a blur.
+func blurPass(tb testing.TB, processor *Processor, src image.Image, horizontal bool, k kernel) *image.RGBA {
+	var result *image.RGBA
+	runErr := processor.run(func() error {
+		source, uploadErr := processor.upload(src)
+		if uploadErr != nil {
+			return uploadErr
+		}
+		defer source.destroy()
+		target, passErr := processor.convolve(source, source.width, source.height, horizontal, k)
+		if passErr != nil {
+			return passErr
+		}
+		defer target.destroy()
+		var downloadErr error
+		result, downloadErr = processor.download(target)
+		return downloadErr
+	})
+	if runErr != nil {
+		tb.Fatal(runErr)
+	}
+	return result
+}
+
+// As in TestResize, each pass is checked on its own.
+func TestGaussianBlur(t *testing.T) {
+	processor := newTestProcessor(t)
+	src := newTestImage(64, 48)
+	bounds := src.Bounds()
+
+	for _, sigma := range([]float64{0.5, 2, 6}) {
+		t.Run(fmt.Sprint("sigma", sigma), func(t *testing.T) {
+			k := gaussianKernel(sigma)
+			source, copyErr := copyRGBA(src)
+			if copyErr != nil {
+				t.Fatal(copyErr)
+			}
+			horizontal := blurPass(t, processor, src, true, k)
+			want, wantErr := referenceConvolve(source, bounds.Dx(), bounds.Dy(), true, k)
+			if wantErr != nil {
+				t.Fatal(wantErr)
+			}
+			compareImages(t, horizontal, want)
+
+			got, gotErr := processor.GaussianBlur(src, sigma)
+			if gotErr != nil {
+				t.Fatal(gotErr)
+			}
+			want, wantErr = referenceConvolve(horizontal, bounds.Dx(), bounds.Dy(), false, k)
+			if wantErr != nil {
+				t.Fatal(wantErr)
+			}
+			compareImages(t, got, want)
+		})
+	}
+}
+
+func TestColorMatrix(t *testing.T) {
+	processor := newTestProcessor(t)
+	src := newTestImage(64, 48)
+
+	sepia := ColorMatrix{
+		{0.393, 0.769, 0.189, 0, 0},
+		{0.349, 0.686, 0.168, 0, 0},
+		{0.272, 0.534, 0.131, 0, 0},
+		{0, 0, 0, 0.8, 0.1},
+	}
+	for i, matrix := range([]ColorMatrix{IdentityColorMatrix, sepia}) {
+		t.Run(fmt.Sprint("matrix", i), func(t *testing.T) {
+			got, gotErr := processor.ColorMatrix(src, matrix)
+			if gotErr != nil {
+				t.Fatal(gotErr)
+			}
+			want, wantErr := ReferenceColorMatrix(src, matrix)
+			if wantErr != nil {
+				t.Fatal(wantErr)
+			}
+			compareImages(t, got, want)
+		})
+	}
+}
+
+func TestReferenceEmpty(t *testing.T) {
+	empty := image.NewRGBA(image.Rect(0, 0, 0, 0))
+	_, resizeErr := ReferenceResize(empty, 4, 4, Bilinear)
+	if resizeErr == nil {
+		t.Error("ReferenceResize accepted an empty image")
+	}
+	_, blurErr := ReferenceGaussianBlur(empty, 1)
+	if blurErr == nil {
+		t.Error("ReferenceGaussianBlur accepted an empty image")
+	}
+	_, matrixErr := ReferenceColorMatrix(empty, IdentityColorMatrix)
+	if matrixErr == nil {
+		t.Error("ReferenceColorMatrix accepted an empty image")
+	}
+	_, convertErr := ReferenceConvert(empty, FormatGray)
+	if convertErr == nil {
+		t.Error("ReferenceConvert accepted an empty image")
+	}
+}
+
+func TestConvert(t *testing.T) {
+	processor := newTestProcessor(t)
+	src := newTestImage(64, 48)
+
+	for _, format := range([]Format{FormatRGBA, FormatNRGBA, FormatGray}) {
+		t.Run(fmt.Sprint("format", format), func(t *testing.T) {
+			got, gotErr := processor.Convert(src, format)
+			if gotErr != nil {
+				t.Fatal(gotErr)
+			}
+			want, wantErr := ReferenceConvert(src, format)
+			if wantErr != nil {
+				t.Fatal(wantErr)
+			}
+			compareImages(t, got, want)
+		})
+	}
+}
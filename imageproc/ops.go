@@ -0,0 +1,312 @@
+package imageproc
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+)
+
+// Filter selects the kernel Resize samples with.
+type Filter int
+
+const (
+	// Bilinear uses a triangle kernel, widened when shrinking.
+	Bilinear Filter = iota
+	// Lanczos3 uses a three lobe Lanczos kernel, widened when shrinking.
+	Lanczos3
+)
+
+// Format is the Go image type Convert produces.
+type Format int
+
+const (
+	FormatRGBA Format = iota // *image.RGBA
+	FormatNRGBA // *image.NRGBA
+	FormatGray // *image.Gray
+)
+
+/*
+ * ColorMatrix transforms unpremultiplied colors with components from 0 to 1.
+ * Each row computes one output component, red, green, blue then alpha, as
+ * the dot product of the first four columns with the input red, green, blue
+ * and alpha, plus the fifth column.
+ */
+type ColorMatrix [4][5]float32
+
+// IdentityColorMatrix leaves colors unchanged.
+var IdentityColorMatrix = ColorMatrix{
+	{1, 0, 0, 0, 0},
+	{0, 1, 0, 0, 0},
+	{0, 0, 1, 0, 0},
+	{0, 0, 0, 1, 0},
+}
+
+// kernel numbers, as used by convolveShader
+const (
+	kernelTriangle = 0
+	kernelLanczos3 = 1
+	kernelGaussian = 2
+)
+
+// the shader's loop limit
+const maxTaps = 256
+
+type kernel struct {
+	kind int
+	radius float64
+	sigma float64
+}
+
+func resizeKernel(filter Filter) (kernel, error) {
+	switch filter {
+		case Bilinear:
+			return kernel{kind: kernelTriangle, radius: 1}, nil
+		case Lanczos3:
+			return kernel{kind: kernelLanczos3, radius: 3}, nil
+	}
+	return kernel{}, fmt.Errorf("unknown filter %d", filter)
+}
+
+func gaussianKernel(sigma float64) kernel {
+	return kernel{kind: kernelGaussian, radius: math.Ceil(3 * sigma), sigma: sigma}
+}
+
+/*
+ * axis returns how a pass from sourceLength to destLength pixels samples:
+ * source pixels per destination pixel, how far the kernel is stretched, and
+ * the kernel's radius in source pixels.
+ */
+func (k kernel) axis(sourceLength, destLength int) (scale, stretch, support float64, err error) {
+	scale = float64(sourceLength) / float64(destLength)
+	stretch = 1
+	if k.kind != kernelGaussian && scale > 1 {
+		stretch = scale
+	}
+	support = k.radius * stretch
+	if 2 * support + 1 > maxTaps {
+		return 0, 0, 0, errors.New("kernel is too wide, shrink or blur in steps")
+	}
+	return scale, stretch, support, nil
+}
+
+func (k kernel) weight(x float64) float64 {
+	switch k.kind {
+		case kernelTriangle:
+			return math.Max(0, 1 - math.Abs(x))
+		case kernelLanczos3:
+			if math.Abs(x) >= 3 {
+				return 0
+			}
+			return sinc(x) * sinc(x / 3)
+	}
+	return math.Exp(-0.5 * x * x / (k.sigma * k.sigma))
+}
+
+func sinc(x float64) float64 {
+	if math.Abs(x) < 1e-5 {
+		return 1
+	}
+	x *= math.Pi
+	return math.Sin(x) / x
+}
+
+// Resize scales src to width by height.
+func (processor *Processor) Resize(src image.Image, width, height int, filter Filter) (*image.RGBA, error) {
+	positiveErr := checkPositive(width, height)
+	if positiveErr != nil {
+		return nil, positiveErr
+	}
+	k, filterErr := resizeKernel(filter)
+	if filterErr != nil {
+		return nil, filterErr
+	}
+
+	var result *image.RGBA
+	runErr := processor.run(func() error {
+		var passErr error
+		result, passErr = processor.separable(src, width, height, k)
+		return passErr
+	})
+	return result, runErr
+}
+
+/*
+ * GaussianBlur blurs src with a gaussian of standard deviation sigma pixels,
+ * repeating edge pixels beyond the edges.
+ */
+func (processor *Processor) GaussianBlur(src image.Image, sigma float64) (*image.RGBA, error) {
+	if sigma < 0 {
+		return nil, errors.New("sigma must not be negative")
+	}
+	bounds := src.Bounds()
+	k := gaussianKernel(sigma)
+
+	var result *image.RGBA
+	runErr := processor.run(func() error {
+		var passErr error
+		result, passErr = processor.separable(src, bounds.Dx(), bounds.Dy(), k)
+		return passErr
+	})
+	return result, runErr
+}
+
+/*
+ * separable uploads src and convolves it with k horizontally then
+ * vertically. Resizing skips the pass for an axis whose size is unchanged,
+ * as does a blur with a sigma of 0.
+ */
+func (processor *Processor) separable(src image.Image, width, height int, k kernel) (*image.RGBA, error) {
+	sizeErr := processor.checkSize(width, height)
+	if sizeErr != nil {
+		return nil, sizeErr
+	}
+	current, uploadErr := processor.upload(src)
+	if uploadErr != nil {
+		return nil, uploadErr
+	}
+	defer func() {
+		current.destroy()
+	}()
+
+	blur := k.kind == kernelGaussian
+	if (blur && k.sigma > 0) || current.width != width {
+		next, passErr := processor.convolve(current, width, current.height, true, k)
+		if passErr != nil {
+			return nil, passErr
+		}
+		current.destroy()
+		current = next
+	}
+	if (blur && k.sigma > 0) || current.height != height {
+		next, passErr := processor.convolve(current, current.width, height, false, k)
+		if passErr != nil {
+			return nil, passErr
+		}
+		current.destroy()
+		current = next
+	}
+	return processor.download(current)
+}
+
+func (processor *Processor) convolve(source *texture, width, height int, horizontal bool, k kernel) (*texture, error) {
+	sourceLength, destLength := source.height, height
+	directionX, directionY := 0, 1
+	if horizontal {
+		sourceLength, destLength = source.width, width
+		directionX, directionY = 1, 0
+	}
+	scale, stretch, support, axisErr := k.axis(sourceLength, destLength)
+	if axisErr != nil {
+		return nil, axisErr
+	}
+
+	p := processor.convolveProgram
+	return processor.pass(p, source, width, height, func() {
+		glUniform2f(p.uniform("u_direction"), float32(directionX), float32(directionY))
+		glUniform1f(p.uniform("u_scale"), float32(scale))
+		glUniform1f(p.uniform("u_stretch"), float32(stretch))
+		glUniform1f(p.uniform("u_support"), float32(support))
+		glUniform1i(p.uniform("u_kernel"), k.kind)
+		glUniform1f(p.uniform("u_sigma"), float32(k.sigma))
+	})
+}
+
+// ColorMatrix transforms every pixel of src with matrix.
+func (processor *Processor) ColorMatrix(src image.Image, matrix ColorMatrix) (*image.RGBA, error) {
+	// GLES 2 only takes column-major matrices
+	var columns [16]float32
+	var offset [4]float32
+	for row := 0; row < 4; row++ {
+		for column := 0; column < 4; column++ {
+			columns[column * 4 + row] = matrix[row][column]
+		}
+		offset[row] = matrix[row][4]
+	}
+
+	var result *image.RGBA
+	runErr := processor.run(func() error {
+		source, uploadErr := processor.upload(src)
+		if uploadErr != nil {
+			return uploadErr
+		}
+		defer source.destroy()
+
+		p := processor.matrixProgram
+		target, passErr := processor.pass(p, source, source.width, source.height, func() {
+			glUniformMatrix4fv(p.uniform("u_matrix"), columns)
+			glUniform4f(p.uniform("u_offset"), offset)
+		})
+		if passErr != nil {
+			return passErr
+		}
+		defer target.destroy()
+
+		var downloadErr error
+		result, downloadErr = processor.download(target)
+		return downloadErr
+	})
+	return result, runErr
+}
+
+/*
+ * Convert converts src to another Go image type on the GPU. FormatGray
+ * ignores alpha, like color.GrayModel.
+ */
+func (processor *Processor) Convert(src image.Image, format Format) (image.Image, error) {
+	var shaderFormat int
+	switch format {
+		case FormatRGBA:
+		case FormatNRGBA:
+			shaderFormat = 1
+		case FormatGray:
+			shaderFormat = 0
+		default:
+			return nil, fmt.Errorf("unknown format %d", format)
+	}
+
+	var result image.Image
+	runErr := processor.run(func() error {
+		source, uploadErr := processor.upload(src)
+		if uploadErr != nil {
+			return uploadErr
+		}
+		defer source.destroy()
+
+		target := source
+		if format != FormatRGBA {
+			p := processor.convertProgram
+			var passErr error
+			target, passErr = processor.pass(p, source, source.width, source.height, func() {
+				glUniform1i(p.uniform("u_format"), shaderFormat)
+			})
+			if passErr != nil {
+				return passErr
+			}
+			defer target.destroy()
+		}
+
+		rgba, downloadErr := processor.download(target)
+		if downloadErr != nil {
+			return downloadErr
+		}
+		result = repackRGBA(rgba, format)
+		return nil
+	})
+	return result, runErr
+}
+
+// repackRGBA gives the pixels read back from a conversion their Go type.
+func repackRGBA(rgba *image.RGBA, format Format) image.Image {
+	switch format {
+		case FormatNRGBA:
+			return &image.NRGBA{Pix: rgba.Pix, Stride: rgba.Stride, Rect: rgba.Rect}
+		case FormatGray:
+			gray := image.NewGray(rgba.Rect)
+			for i := range(gray.Pix) {
+				gray.Pix[i] = rgba.Pix[i * 4]
+			}
+			return gray
+	}
+	return rgba
+}
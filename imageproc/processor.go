@@ -0,0 +1,485 @@
+/*
+ * Package imageproc resizes, blurs and color converts Go images on the GPU.
+ * Images are uploaded into GLES 2 textures on a context the package manages
+ * itself, processed with shader passes, and read back into Go images. It
+ * prefers Mesa's surfaceless platform, so it runs headless, including on
+ * llvmpipe without a GPU.
+ *
+ * Pixels are processed premultiplied with 8 bits per component, also
+ * between passes. The Reference functions compute the same results on the
+ * CPU, to within rounding.
+ */
+package imageproc
+
+/*
+#cgo pkg-config: glesv2
+
+#include <GLES2/gl2.h>
+#include <stdlib.h>
+
+// compileProgram links a vertex and fragment shader, writing any compiler
+// or linker log to log and returning 0 on failure.
+static GLuint compileProgram(const char *vertexSource, const char *fragmentSource, char *log, int logSize) {
+	const char *sources[2] = {vertexSource, fragmentSource};
+	GLenum types[2] = {GL_VERTEX_SHADER, GL_FRAGMENT_SHADER};
+	GLuint shaders[2];
+	GLint status;
+
+	for (int i = 0; i < 2; i++) {
+		shaders[i] = glCreateShader(types[i]);
+		glShaderSource(shaders[i], 1, &sources[i], NULL);
+		glCompileShader(shaders[i]);
+		glGetShaderiv(shaders[i], GL_COMPILE_STATUS, &status);
+		if (!status) {
+			glGetShaderInfoLog(shaders[i], logSize, NULL, log);
+			for (int j = 0; j <= i; j++) {
+				glDeleteShader(shaders[j]);
+			}
+			return 0;
+		}
+	}
+
+	GLuint program = glCreateProgram();
+	glAttachShader(program, shaders[0]);
+	glAttachShader(program, shaders[1]);
+	glBindAttribLocation(program, 0, "a_position");
+	glLinkProgram(program);
+	glDeleteShader(shaders[0]);
+	glDeleteShader(shaders[1]);
+	glGetProgramiv(program, GL_LINK_STATUS, &status);
+	if (!status) {
+		glGetProgramInfoLog(program, logSize, NULL, log);
+		glDeleteProgram(program);
+		return 0;
+	}
+	return program;
+}
+
+static GLuint createQuad(void) {
+	static const GLfloat corners[8] = {-1, -1, 1, -1, -1, 1, 1, 1};
+	GLuint buffer;
+	glGenBuffers(1, &buffer);
+	glBindBuffer(GL_ARRAY_BUFFER, buffer);
+	glBufferData(GL_ARRAY_BUFFER, sizeof(corners), corners, GL_STATIC_DRAW);
+	return buffer;
+}
+
+static void drawQuad(GLuint buffer) {
+	glBindBuffer(GL_ARRAY_BUFFER, buffer);
+	glEnableVertexAttribArray(0);
+	glVertexAttribPointer(0, 2, GL_FLOAT, GL_FALSE, 0, 0);
+	glDrawArrays(GL_TRIANGLE_STRIP, 0, 4);
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"runtime"
+	"sync"
+	"unsafe"
+
+	"github.com/foobaz/egl"
+)
+
+/*
+ * Processor owns a GLES 2 context and the thread it is current on. Its
+ * methods may be called from any goroutine; work is done one request at a
+ * time on the processor's own locked OS thread.
+ */
+type Processor struct {
+	requests chan func()
+	done chan struct{}
+	lock sync.Mutex
+	closed bool
+
+	display *egl.Display
+	context *egl.Context
+	surface *egl.Surface
+
+	quad C.GLuint
+	framebuffer C.GLuint
+	maxTextureSize int
+	convolveProgram *program
+	matrixProgram *program
+	convertProgram *program
+}
+
+type program struct {
+	id C.GLuint
+	uniforms map[string]C.GLint
+}
+
+// texture is an RGBA texture with 8 bits per component.
+type texture struct {
+	id C.GLuint
+	width, height int
+}
+
+/*
+ * NewProcessor creates a context on the surfaceless platform if available,
+ * and on the default display otherwise.
+ */
+func NewProcessor() (*Processor, error) {
+	processor := new(Processor)
+	processor.requests = make(chan func())
+	processor.done = make(chan struct{})
+
+	setupResult := make(chan error)
+	go processor.serve(setupResult)
+	setupErr := <-setupResult
+	if setupErr != nil {
+		return nil, setupErr
+	}
+	return processor, nil
+}
+
+func (processor *Processor) serve(setupResult chan<- error) {
+	runtime.LockOSThread()
+	defer close(processor.done)
+
+	setupErr := processor.setup()
+	if setupErr != nil {
+		processor.teardown()
+		setupResult <- setupErr
+		return
+	}
+	setupResult <- nil
+
+	for request := range(processor.requests) {
+		request()
+	}
+	processor.teardown()
+}
+
+/*
+ * openDisplay opens and initializes the surfaceless display, falling back
+ * to the default display when either step fails.
+ */
+func openDisplay() (*egl.Display, error) {
+	display, displayErr := egl.OpenSurfacelessDisplay()
+	if displayErr == nil {
+		initErr := display.Initialize()
+		if initErr == nil {
+			return display, nil
+		}
+		display.Close()
+	}
+
+	display, displayErr = egl.OpenDisplay()
+	if displayErr != nil {
+		return nil, displayErr
+	}
+	initErr := display.Initialize()
+	if initErr != nil {
+		display.Close()
+		return nil, initErr
+	}
+	return display, nil
+}
+
+func (processor *Processor) setup() error {
+	display, displayErr := openDisplay()
+	if displayErr != nil {
+		return displayErr
+	}
+	processor.display = display
+
+	bindErr := egl.BindAPI(egl.OpenGLESAPI)
+	if bindErr != nil {
+		return bindErr
+	}
+	configs, configErr := display.ChooseConfig([]egl.Attrib{
+		egl.SurfaceType, egl.PbufferBit,
+		egl.RenderableType, egl.OpenGLES2Bit,
+		egl.RedSize, 8,
+		egl.GreenSize, 8,
+		egl.BlueSize, 8,
+		egl.AlphaSize, 8,
+		egl.None,
+	})
+	if configErr != nil {
+		return configErr
+	}
+	if len(configs) == 0 {
+		return errors.New("no RGBA8 pbuffer config supports GLES 2")
+	}
+
+	// rendering goes to framebuffer objects, the pbuffer only makes the
+	// context current
+	surface, surfaceErr := display.CreatePbufferSurface(configs[0], []egl.Attrib{egl.Width, 1, egl.Height, 1, egl.None})
+	if surfaceErr != nil {
+		return surfaceErr
+	}
+	processor.surface = surface
+	context, contextErr := display.CreateContext(configs[0], nil, []egl.Attrib{egl.ContextClientVersion, 2, egl.None})
+	if contextErr != nil {
+		return contextErr
+	}
+	processor.context = context
+	makeErr := context.MakeCurrent(surface, surface)
+	if makeErr != nil {
+		return makeErr
+	}
+
+	var maxSize C.GLint
+	C.glGetIntegerv(C.GL_MAX_TEXTURE_SIZE, &maxSize)
+	processor.maxTextureSize = int(maxSize)
+	C.glPixelStorei(C.GL_UNPACK_ALIGNMENT, 1)
+	C.glPixelStorei(C.GL_PACK_ALIGNMENT, 1)
+	C.glDisable(C.GL_BLEND)
+	C.glDisable(C.GL_DITHER)
+
+	processor.quad = C.createQuad()
+	C.glGenFramebuffers(1, &processor.framebuffer)
+
+	var programErr error
+	processor.convolveProgram, programErr = newProgram(convolveShader)
+	if programErr != nil {
+		return programErr
+	}
+	processor.matrixProgram, programErr = newProgram(matrixShader)
+	if programErr != nil {
+		return programErr
+	}
+	processor.convertProgram, programErr = newProgram(convertShader)
+	if programErr != nil {
+		return programErr
+	}
+	return checkGLError("setup")
+}
+
+func (processor *Processor) teardown() {
+	if processor.context != nil {
+		for _, p := range([]*program{processor.convolveProgram, processor.matrixProgram, processor.convertProgram}) {
+			if p != nil {
+				C.glDeleteProgram(p.id)
+			}
+		}
+		if processor.framebuffer != 0 {
+			C.glDeleteFramebuffers(1, &processor.framebuffer)
+		}
+		if processor.quad != 0 {
+			C.glDeleteBuffers(1, &processor.quad)
+		}
+		processor.context.Destroy()
+	}
+	if processor.surface != nil {
+		processor.surface.Destroy()
+	}
+	if processor.display != nil {
+		processor.display.Close()
+	}
+}
+
+// Close destroys the context and stops the processor's thread.
+func (processor *Processor) Close() error {
+	processor.lock.Lock()
+	if processor.closed {
+		processor.lock.Unlock()
+		return errors.New("processor already closed")
+	}
+	processor.closed = true
+	close(processor.requests)
+	processor.lock.Unlock()
+
+	<-processor.done
+	return nil
+}
+
+// run does work on the processor's thread and waits for it.
+func (processor *Processor) run(work func() error) error {
+	processor.lock.Lock()
+	if processor.closed {
+		processor.lock.Unlock()
+		return errors.New("processor is closed")
+	}
+	result := make(chan error, 1)
+	processor.requests <- func() {
+		result <- work()
+	}
+	processor.lock.Unlock()
+	return <-result
+}
+
+func newProgram(fragmentSource string) (*program, error) {
+	cVertex := C.CString(vertexShader)
+	defer C.free(unsafe.Pointer(cVertex))
+	cFragment := C.CString(fragmentSource)
+	defer C.free(unsafe.Pointer(cFragment))
+
+	var log [1024]C.char
+	id := C.compileProgram(cVertex, cFragment, &log[0], C.int(len(log)))
+	if id == 0 {
+		return nil, fmt.Errorf("shader failed to build: %s", C.GoString(&log[0]))
+	}
+
+	p := new(program)
+	p.id = id
+	p.uniforms = make(map[string]C.GLint)
+	return p, nil
+}
+
+func (p *program) uniform(name string) C.GLint {
+	location, found := p.uniforms[name]
+	if !found {
+		cName := C.CString(name)
+		location = C.glGetUniformLocation(p.id, cName)
+		C.free(unsafe.Pointer(cName))
+		p.uniforms[name] = location
+	}
+	return location
+}
+
+func checkGLError(operation string) error {
+	glErr := C.glGetError()
+	if glErr != C.GL_NO_ERROR {
+		return fmt.Errorf("%s failed with GL error 0x%X", operation, glErr)
+	}
+	return nil
+}
+
+func checkPositive(width, height int) error {
+	if width <= 0 || height <= 0 {
+		return errors.New("image width and height must be positive")
+	}
+	return nil
+}
+
+func (processor *Processor) checkSize(width, height int) error {
+	positiveErr := checkPositive(width, height)
+	if positiveErr != nil {
+		return positiveErr
+	}
+	if width > processor.maxTextureSize || height > processor.maxTextureSize {
+		return fmt.Errorf("%dx%d image is larger than the maximum texture size of %d", width, height, processor.maxTextureSize)
+	}
+	return nil
+}
+
+func (processor *Processor) newTexture(width, height int, pixels []byte) (*texture, error) {
+	sizeErr := processor.checkSize(width, height)
+	if sizeErr != nil {
+		return nil, sizeErr
+	}
+
+	t := new(texture)
+	t.width = width
+	t.height = height
+	C.glGenTextures(1, &t.id)
+	C.glBindTexture(C.GL_TEXTURE_2D, t.id)
+	// passes sample exact texel centers
+	C.glTexParameteri(C.GL_TEXTURE_2D, C.GL_TEXTURE_MIN_FILTER, C.GL_NEAREST)
+	C.glTexParameteri(C.GL_TEXTURE_2D, C.GL_TEXTURE_MAG_FILTER, C.GL_NEAREST)
+	C.glTexParameteri(C.GL_TEXTURE_2D, C.GL_TEXTURE_WRAP_S, C.GL_CLAMP_TO_EDGE)
+	C.glTexParameteri(C.GL_TEXTURE_2D, C.GL_TEXTURE_WRAP_T, C.GL_CLAMP_TO_EDGE)
+
+	var data unsafe.Pointer
+	if pixels != nil {
+		data = unsafe.Pointer(&pixels[0])
+	}
+	C.glTexImage2D(C.GL_TEXTURE_2D, 0, C.GL_RGBA, C.GLsizei(width), C.GLsizei(height), 0, C.GL_RGBA, C.GL_UNSIGNED_BYTE, data)
+	uploadErr := checkGLError("glTexImage2D")
+	if uploadErr != nil {
+		t.destroy()
+		return nil, uploadErr
+	}
+	return t, nil
+}
+
+// upload copies src into a new texture, premultiplied, with row 0 first.
+func (processor *Processor) upload(src image.Image) (*texture, error) {
+	rgba := toRGBA(src)
+	return processor.newTexture(rgba.Rect.Dx(), rgba.Rect.Dy(), rgba.Pix)
+}
+
+func (t *texture) destroy() {
+	C.glDeleteTextures(1, &t.id)
+}
+
+/*
+ * toRGBA returns src as an *image.RGBA whose pixels start at the origin
+ * with no padding, copying only if needed.
+ */
+func toRGBA(src image.Image) *image.RGBA {
+	bounds := src.Bounds()
+	rgba, ok := src.(*image.RGBA)
+	if ok && rgba.Rect.Min == (image.Point{}) && rgba.Stride == bounds.Dx() * 4 {
+		return rgba
+	}
+	rgba = image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Rect, src, bounds.Min, draw.Src)
+	return rgba
+}
+
+/*
+ * pass runs p over the whole of a new width by height texture, sampling
+ * source, after setUniforms has set p's other uniforms.
+ */
+func (processor *Processor) pass(p *program, source *texture, width, height int, setUniforms func()) (*texture, error) {
+	target, targetErr := processor.newTexture(width, height, nil)
+	if targetErr != nil {
+		return nil, targetErr
+	}
+
+	C.glBindFramebuffer(C.GL_FRAMEBUFFER, processor.framebuffer)
+	C.glFramebufferTexture2D(C.GL_FRAMEBUFFER, C.GL_COLOR_ATTACHMENT0, C.GL_TEXTURE_2D, target.id, 0)
+	status := C.glCheckFramebufferStatus(C.GL_FRAMEBUFFER)
+	if status != C.GL_FRAMEBUFFER_COMPLETE {
+		target.destroy()
+		return nil, fmt.Errorf("framebuffer is incomplete, status 0x%X", status)
+	}
+
+	C.glViewport(0, 0, C.GLsizei(width), C.GLsizei(height))
+	C.glUseProgram(p.id)
+	C.glActiveTexture(C.GL_TEXTURE0)
+	C.glBindTexture(C.GL_TEXTURE_2D, source.id)
+	C.glUniform1i(p.uniform("u_source"), 0)
+	C.glUniform2f(p.uniform("u_sourceSize"), C.GLfloat(source.width), C.GLfloat(source.height))
+	setUniforms()
+	C.drawQuad(processor.quad)
+
+	drawErr := checkGLError("drawing pass")
+	if drawErr != nil {
+		target.destroy()
+		return nil, drawErr
+	}
+	return target, nil
+}
+
+// download reads t back into an *image.RGBA, with row 0 first.
+func (processor *Processor) download(t *texture) (*image.RGBA, error) {
+	C.glBindFramebuffer(C.GL_FRAMEBUFFER, processor.framebuffer)
+	C.glFramebufferTexture2D(C.GL_FRAMEBUFFER, C.GL_COLOR_ATTACHMENT0, C.GL_TEXTURE_2D, t.id, 0)
+
+	rgba := image.NewRGBA(image.Rect(0, 0, t.width, t.height))
+	C.glReadPixels(0, 0, C.GLsizei(t.width), C.GLsizei(t.height), C.GL_RGBA, C.GL_UNSIGNED_BYTE, unsafe.Pointer(&rgba.Pix[0]))
+	readErr := checkGLError("glReadPixels")
+	if readErr != nil {
+		return nil, readErr
+	}
+	return rgba, nil
+}
+
+func glUniform1i(location C.GLint, value int) {
+	C.glUniform1i(location, C.GLint(value))
+}
+
+func glUniform1f(location C.GLint, value float32) {
+	C.glUniform1f(location, C.GLfloat(value))
+}
+
+func glUniform2f(location C.GLint, x, y float32) {
+	C.glUniform2f(location, C.GLfloat(x), C.GLfloat(y))
+}
+
+func glUniform4f(location C.GLint, value [4]float32) {
+	C.glUniform4f(location, C.GLfloat(value[0]), C.GLfloat(value[1]), C.GLfloat(value[2]), C.GLfloat(value[3]))
+}
+
+func glUniformMatrix4fv(location C.GLint, columns [16]float32) {
+	C.glUniformMatrix4fv(location, 1, C.GL_FALSE, (*C.GLfloat)(unsafe.Pointer(&columns[0])))
+}
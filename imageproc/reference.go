@@ -0,0 +1,214 @@
+package imageproc
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+)
+
+/*
+ * The Reference functions compute on the CPU what the Processor methods of
+ * the same names compute on the GPU, with the same kernels, edge handling
+ * and 8-bit rounding between passes. Their results differ from the GPU's
+ * only by float rounding, normally a step or two per component. They are
+ * slow, and meant for checking drivers and shaders. Like the Processor
+ * methods, they return an error for empty images.
+ */
+
+// ReferenceResize is Processor.Resize on the CPU.
+func ReferenceResize(src image.Image, width, height int, filter Filter) (*image.RGBA, error) {
+	positiveErr := checkPositive(width, height)
+	if positiveErr != nil {
+		return nil, positiveErr
+	}
+	k, filterErr := resizeKernel(filter)
+	if filterErr != nil {
+		return nil, filterErr
+	}
+	return referenceSeparable(src, width, height, k)
+}
+
+// ReferenceGaussianBlur is Processor.GaussianBlur on the CPU.
+func ReferenceGaussianBlur(src image.Image, sigma float64) (*image.RGBA, error) {
+	if sigma < 0 {
+		return nil, errors.New("sigma must not be negative")
+	}
+	bounds := src.Bounds()
+	return referenceSeparable(src, bounds.Dx(), bounds.Dy(), gaussianKernel(sigma))
+}
+
+func referenceSeparable(src image.Image, width, height int, k kernel) (*image.RGBA, error) {
+	current, copyErr := copyRGBA(src)
+	if copyErr != nil {
+		return nil, copyErr
+	}
+	blur := k.kind == kernelGaussian
+	if (blur && k.sigma > 0) || current.Rect.Dx() != width {
+		next, passErr := referenceConvolve(current, width, current.Rect.Dy(), true, k)
+		if passErr != nil {
+			return nil, passErr
+		}
+		current = next
+	}
+	if (blur && k.sigma > 0) || current.Rect.Dy() != height {
+		next, passErr := referenceConvolve(current, current.Rect.Dx(), height, false, k)
+		if passErr != nil {
+			return nil, passErr
+		}
+		current = next
+	}
+	return current, nil
+}
+
+// referenceConvolve is convolveShader on the CPU.
+func referenceConvolve(source *image.RGBA, width, height int, horizontal bool, k kernel) (*image.RGBA, error) {
+	sourceLength, destLength := source.Rect.Dy(), height
+	if horizontal {
+		sourceLength, destLength = source.Rect.Dx(), width
+	}
+	scale, stretch, support, axisErr := k.axis(sourceLength, destLength)
+	if axisErr != nil {
+		return nil, axisErr
+	}
+
+	dest := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			along, across := y, x
+			if horizontal {
+				along, across = x, y
+			}
+			center := (float64(along) + 0.5) * scale - 0.5
+			var sum [4]float64
+			var total float64
+			for index := math.Floor(center - support) + 1; index <= center + support; index++ {
+				w := k.weight((index - center) / stretch)
+				sample := int(math.Max(0, math.Min(index, float64(sourceLength - 1))))
+				offset := source.PixOffset(across, sample)
+				if horizontal {
+					offset = source.PixOffset(sample, across)
+				}
+				for c := 0; c < 4; c++ {
+					sum[c] += float64(source.Pix[offset + c]) / 255 * w
+				}
+				total += w
+			}
+
+			var color [4]float64
+			for c := 0; c < 4; c++ {
+				color[c] = clamp01(sum[c] / total)
+			}
+			for c := 0; c < 3; c++ {
+				color[c] = math.Min(color[c], color[3])
+			}
+			setPixel(dest, x, y, color)
+		}
+	}
+	return dest, nil
+}
+
+// ReferenceColorMatrix is Processor.ColorMatrix on the CPU.
+func ReferenceColorMatrix(src image.Image, matrix ColorMatrix) (*image.RGBA, error) {
+	rgba, copyErr := copyRGBA(src)
+	if copyErr != nil {
+		return nil, copyErr
+	}
+	bounds := rgba.Rect
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			in := getPixel(rgba, x, y)
+			if in[3] > 0 {
+				for c := 0; c < 3; c++ {
+					in[c] /= in[3]
+				}
+			}
+			var out [4]float64
+			for row := 0; row < 4; row++ {
+				sum := float64(matrix[row][4])
+				for column := 0; column < 4; column++ {
+					sum += float64(matrix[row][column]) * in[column]
+				}
+				out[row] = clamp01(sum)
+			}
+			for c := 0; c < 3; c++ {
+				out[c] *= out[3]
+			}
+			setPixel(rgba, x, y, out)
+		}
+	}
+	return rgba, nil
+}
+
+// ReferenceConvert is Processor.Convert on the CPU.
+func ReferenceConvert(src image.Image, format Format) (image.Image, error) {
+	rgba, copyErr := copyRGBA(src)
+	if copyErr != nil {
+		return nil, copyErr
+	}
+	bounds := rgba.Rect
+	switch format {
+		case FormatRGBA:
+			return rgba, nil
+		case FormatNRGBA:
+			for y := 0; y < bounds.Dy(); y++ {
+				for x := 0; x < bounds.Dx(); x++ {
+					color := getPixel(rgba, x, y)
+					if color[3] > 0 {
+						for c := 0; c < 3; c++ {
+							color[c] /= color[3]
+						}
+					}
+					setPixel(rgba, x, y, color)
+				}
+			}
+		case FormatGray:
+			for y := 0; y < bounds.Dy(); y++ {
+				for x := 0; x < bounds.Dx(); x++ {
+					color := getPixel(rgba, x, y)
+					luma := clamp01(color[0] * 0.299 + color[1] * 0.587 + color[2] * 0.114)
+					setPixel(rgba, x, y, [4]float64{luma, luma, luma, 1})
+				}
+			}
+		default:
+			return nil, fmt.Errorf("unknown format %d", format)
+	}
+	return repackRGBA(rgba, format), nil
+}
+
+// copyRGBA returns a copy of src that can be modified in place.
+func copyRGBA(src image.Image) (*image.RGBA, error) {
+	bounds := src.Bounds()
+	positiveErr := checkPositive(bounds.Dx(), bounds.Dy())
+	if positiveErr != nil {
+		return nil, positiveErr
+	}
+	rgba := toRGBA(src)
+	if rgba != src {
+		return rgba, nil
+	}
+	duplicate := image.NewRGBA(rgba.Rect)
+	copy(duplicate.Pix, rgba.Pix)
+	return duplicate, nil
+}
+
+func getPixel(rgba *image.RGBA, x, y int) [4]float64 {
+	offset := rgba.PixOffset(x, y)
+	var color [4]float64
+	for c := 0; c < 4; c++ {
+		color[c] = float64(rgba.Pix[offset + c]) / 255
+	}
+	return color
+}
+
+// setPixel rounds to the nearest 8-bit value, as GL does for unorm targets.
+func setPixel(rgba *image.RGBA, x, y int, color [4]float64) {
+	offset := rgba.PixOffset(x, y)
+	for c := 0; c < 4; c++ {
+		rgba.Pix[offset + c] = uint8(color[c] * 255 + 0.5)
+	}
+}
+
+func clamp01(value float64) float64 {
+	return math.Max(0, math.Min(value, 1))
+}
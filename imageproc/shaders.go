@@ -0,0 +1,126 @@
+package imageproc
+
+// Every pass draws one quad covering its target texture.
+const vertexShader = `
+attribute vec2 a_position;
+
+void main() {
+	gl_Position = vec4(a_position, 0.0, 1.0);
+}
+`
+
+const fragmentHeader = `
+#ifdef GL_FRAGMENT_PRECISION_HIGH
+precision highp float;
+#else
+precision mediump float;
+#endif
+
+uniform sampler2D u_source;
+uniform vec2 u_sourceSize;
+`
+
+/*
+ * convolveShader filters along one axis, so resizing and blurring take a
+ * horizontal pass and a vertical pass. Destination pixel x samples source
+ * pixels around (x + 0.5) * u_scale - 0.5; when shrinking, the kernel is
+ * stretched by u_scale so every source pixel contributes. Taps beyond the
+ * edge repeat the edge pixel. The kernel numbers match the kernel constants
+ * in ops.go.
+ */
+const convolveShader = fragmentHeader + `
+uniform vec2 u_direction;
+uniform float u_scale;
+uniform float u_stretch;
+uniform float u_support;
+uniform int u_kernel;
+uniform float u_sigma;
+
+const int maxTaps = 256;
+const float pi = 3.14159265358979;
+
+float sinc(float x) {
+	if (abs(x) < 1e-5) {
+		return 1.0;
+	}
+	x *= pi;
+	return sin(x) / x;
+}
+
+float weight(float x) {
+	if (u_kernel == 0) {
+		return max(0.0, 1.0 - abs(x));
+	}
+	if (u_kernel == 1) {
+		if (abs(x) >= 3.0) {
+			return 0.0;
+		}
+		return sinc(x) * sinc(x / 3.0);
+	}
+	return exp(-0.5 * x * x / (u_sigma * u_sigma));
+}
+
+void main() {
+	float center = dot(gl_FragCoord.xy, u_direction) * u_scale - 0.5;
+	float across = dot(gl_FragCoord.xy, u_direction.yx);
+	float last = dot(u_sourceSize, u_direction) - 1.0;
+	float first = floor(center - u_support) + 1.0;
+
+	vec4 sum = vec4(0.0);
+	float total = 0.0;
+	for (int i = 0; i < maxTaps; i++) {
+		float index = first + float(i);
+		if (index > center + u_support) {
+			break;
+		}
+		float w = weight((index - center) / u_stretch);
+		float along = clamp(index, 0.0, last) + 0.5;
+		vec2 position = u_direction * along + u_direction.yx * across;
+		sum += texture2D(u_source, position / u_sourceSize) * w;
+		total += w;
+	}
+
+	// lanczos lobes can overshoot, keep the result premultiplied
+	vec4 color = clamp(sum / total, 0.0, 1.0);
+	color.rgb = min(color.rgb, color.a);
+	gl_FragColor = color;
+}
+`
+
+// matrixShader applies a color matrix to unpremultiplied colors.
+const matrixShader = fragmentHeader + `
+uniform mat4 u_matrix;
+uniform vec4 u_offset;
+
+void main() {
+	vec4 color = texture2D(u_source, gl_FragCoord.xy / u_sourceSize);
+	if (color.a > 0.0) {
+		color.rgb /= color.a;
+	}
+	color = clamp(u_matrix * color + u_offset, 0.0, 1.0);
+	color.rgb *= color.a;
+	gl_FragColor = color;
+}
+`
+
+/*
+ * convertShader converts premultiplied colors for readback as another Go
+ * image type: 0 is gray, using the weights of color.GrayModel, and 1 is
+ * unpremultiplied.
+ */
+const convertShader = fragmentHeader + `
+uniform int u_format;
+
+void main() {
+	vec4 color = texture2D(u_source, gl_FragCoord.xy / u_sourceSize);
+	if (u_format == 0) {
+		float luma = dot(color.rgb, vec3(0.299, 0.587, 0.114));
+		gl_FragColor = vec4(luma, luma, luma, 1.0);
+	} else {
+		if (color.a > 0.0) {
+			color.rgb /= color.a;
+		}
+		gl_FragColor = color;
+	}
+}
+`
@@ -11,6 +11,7 @@ type Config C.EGLConfig
 type Attrib C.EGLint
 type NativeDisplay C.EGLNativeDisplayType
 type NativePixmap C.EGLNativePixmapType
+type NativeWindow C.EGLNativeWindowType
 
 func WaitClient() error {
 	success := C.eglWaitClient()
@@ -20,6 +21,14 @@ func WaitClient() error {
 	return nil
 }
 
+func WaitNative() error {
+	success := C.eglWaitNative(C.EGL_CORE_NATIVE_ENGINE)
+	if success == C.EGL_FALSE {
+		return getError()
+	}
+	return nil
+}
+
 func BindAPI(api int) error {
 	success := C.eglBindAPI(C.EGLenum(api))
 	if success == C.EGL_FALSE {
@@ -0,0 +1,297 @@
+package egl
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+/*
+ * pixelFormat describes how an XImage packs pixels: the pixel size, which
+ * bits hold each component, and the order bytes are stored in.
+ */
+type pixelFormat struct {
+	bitsPerPixel int
+	depth int
+	msbFirst bool
+	red, green, blue, alpha channelMask
+}
+
+// channelMask locates one color component within a pixel.
+type channelMask struct {
+	mask uint32
+	shift uint
+	bits uint
+}
+
+func newChannelMask(mask uint32) channelMask {
+	if mask == 0 {
+		return channelMask{}
+	}
+	shift := uint(bits.TrailingZeros32(mask))
+	return channelMask{
+		mask: mask,
+		shift: shift,
+		bits: uint(bits.OnesCount32(mask)),
+	}
+}
+
+/*
+ * newPixelFormat builds a format from XImage fields. Bits within the depth
+ * that aren't red, green or blue are taken to be alpha, which is how 32-bit
+ * ARGB visuals are laid out.
+ */
+func newPixelFormat(bitsPerPixel, depth int, msbFirst bool, red, green, blue uint32) (pixelFormat, error) {
+	switch bitsPerPixel {
+		case 16, 24, 32:
+		default:
+			return pixelFormat{}, fmt.Errorf("unsupported pixel size of %d bits", bitsPerPixel)
+	}
+	if red == 0 || green == 0 || blue == 0 {
+		return pixelFormat{}, fmt.Errorf("unsupported visual, color masks are 0x%X 0x%X 0x%X", red, green, blue)
+	}
+
+	var depthMask uint32 = 0xFFFFFFFF
+	if depth < 32 {
+		depthMask = 1 << uint(depth) - 1
+	}
+	alpha := depthMask &^ (red | green | blue)
+
+	format := pixelFormat{
+		bitsPerPixel: bitsPerPixel,
+		depth: depth,
+		msbFirst: msbFirst,
+		red: newChannelMask(red),
+		green: newChannelMask(green),
+		blue: newChannelMask(blue),
+		alpha: newChannelMask(alpha),
+	}
+	return format, nil
+}
+
+func (format pixelFormat) hasAlpha() bool {
+	return format.alpha.mask != 0
+}
+
+// bytesPerPixel is never less than 2, see newPixelFormat.
+func (format pixelFormat) bytesPerPixel() int {
+	return format.bitsPerPixel / 8
+}
+
+func (format pixelFormat) pixel(row []byte, x int) uint32 {
+	switch format.bitsPerPixel {
+		case 16:
+			b := row[x * 2:x * 2 + 2]
+			if format.msbFirst {
+				return uint32(b[0]) << 8 | uint32(b[1])
+			}
+			return uint32(b[1]) << 8 | uint32(b[0])
+		case 24:
+			b := row[x * 3:x * 3 + 3]
+			if format.msbFirst {
+				return uint32(b[0]) << 16 | uint32(b[1]) << 8 | uint32(b[2])
+			}
+			return uint32(b[2]) << 16 | uint32(b[1]) << 8 | uint32(b[0])
+	}
+	b := row[x * 4:x * 4 + 4]
+	if format.msbFirst {
+		return uint32(b[0]) << 24 | uint32(b[1]) << 16 | uint32(b[2]) << 8 | uint32(b[3])
+	}
+	return uint32(b[3]) << 24 | uint32(b[2]) << 16 | uint32(b[1]) << 8 | uint32(b[0])
+}
+
+// value8 extracts the component from pixel, scaled to 8 bits.
+func (channel channelMask) value8(pixel uint32) uint8 {
+	value := (pixel & channel.mask) >> channel.shift
+	if channel.bits >= 8 {
+		return uint8(value >> (channel.bits - 8))
+	}
+	max := uint32(1) << channel.bits - 1
+	return uint8((value * 0xFF + max / 2) / max)
+}
+
+/*
+ * isBGRA32 reports whether pixels are 8-bit components stored in memory as
+ * blue, green, red, alpha, the common little-endian ARGB layout.
+ */
+func (format pixelFormat) isBGRA32() bool {
+	return format.bitsPerPixel == 32 &&
+		format.red.bits == 8 && format.green.bits == 8 && format.blue.bits == 8 &&
+		format.red.mask == 0xFF0000 && format.green.mask == 0xFF00 && format.blue.mask == 0xFF &&
+		!format.msbFirst
+}
+
+/*
+ * decodeRow converts width pixels from src into 8-bit RGBA in dst. Missing
+ * alpha is filled with 0xFF.
+ */
+func (format pixelFormat) decodeRow(dst, src []byte, width int) {
+	if format.isBGRA32() {
+		for x := 0; x < width; x++ {
+			d := dst[x * 4:x * 4 + 4]
+			s := src[x * 4:x * 4 + 4]
+			d[0] = s[2]
+			d[1] = s[1]
+			d[2] = s[0]
+			if format.alpha.bits == 8 {
+				d[3] = s[3]
+			} else {
+				d[3] = 0xFF
+			}
+		}
+		return
+	}
+
+	for x := 0; x < width; x++ {
+		pixel := format.pixel(src, x)
+		d := dst[x * 4:x * 4 + 4]
+		d[0] = format.red.value8(pixel)
+		d[1] = format.green.value8(pixel)
+		d[2] = format.blue.value8(pixel)
+		if format.hasAlpha() {
+			d[3] = format.alpha.value8(pixel)
+		} else {
+			d[3] = 0xFF
+		}
+	}
+}
+
+// pack scales an 8-bit component to the channel's width and moves it into
+// place.
+func (channel channelMask) pack(value uint8) uint32 {
+	if channel.bits == 0 {
+		return 0
+	}
+	max := uint32(1) << channel.bits - 1
+	scaled := (uint32(value) * max + 0x7F) / 0xFF
+	return scaled << channel.shift & channel.mask
+}
+
+func (format pixelFormat) putPixel(row []byte, x int, pixel uint32) {
+	switch format.bitsPerPixel {
+		case 16:
+			b := row[x * 2:x * 2 + 2]
+			if format.msbFirst {
+				b[0], b[1] = byte(pixel >> 8), byte(pixel)
+			} else {
+				b[0], b[1] = byte(pixel), byte(pixel >> 8)
+			}
+			return
+		case 24:
+			b := row[x * 3:x * 3 + 3]
+			if format.msbFirst {
+				b[0], b[1], b[2] = byte(pixel >> 16), byte(pixel >> 8), byte(pixel)
+			} else {
+				b[0], b[1], b[2] = byte(pixel), byte(pixel >> 8), byte(pixel >> 16)
+			}
+			return
+	}
+	b := row[x * 4:x * 4 + 4]
+	if format.msbFirst {
+		b[0], b[1], b[2], b[3] = byte(pixel >> 24), byte(pixel >> 16), byte(pixel >> 8), byte(pixel)
+	} else {
+		b[0], b[1], b[2], b[3] = byte(pixel), byte(pixel >> 8), byte(pixel >> 16), byte(pixel >> 24)
+	}
+}
+
+// encodeRow is the inverse of decodeRow, packing width 8-bit RGBA pixels
+// from src into dst.
+func (format pixelFormat) encodeRow(dst, src []byte, width int) {
+	if format.isBGRA32() {
+		for x := 0; x < width; x++ {
+			d := dst[x * 4:x * 4 + 4]
+			s := src[x * 4:x * 4 + 4]
+			d[0] = s[2]
+			d[1] = s[1]
+			d[2] = s[0]
+			if format.alpha.bits == 8 {
+				d[3] = s[3]
+			} else {
+				d[3] = 0
+			}
+		}
+		return
+	}
+
+	for x := 0; x < width; x++ {
+		s := src[x * 4:x * 4 + 4]
+		pixel := format.red.pack(s[0]) |
+			format.green.pack(s[1]) |
+			format.blue.pack(s[2]) |
+			format.alpha.pack(s[3])
+		format.putPixel(dst, x, pixel)
+	}
+}
+
+// value16 extracts the component from pixel, scaled to 16 bits.
+func (channel channelMask) value16(pixel uint32) uint16 {
+	value := (pixel & channel.mask) >> channel.shift
+	if channel.bits >= 16 {
+		return uint16(value >> (channel.bits - 16))
+	}
+	max := uint32(1) << channel.bits - 1
+	return uint16((value * 0xFFFF + max / 2) / max)
+}
+
+// isDeep reports whether any color component has more than 8 bits, like
+// 30-bit visuals, so 8-bit images would lose precision.
+func (format pixelFormat) isDeep() bool {
+	return format.red.bits > 8 || format.green.bits > 8 || format.blue.bits > 8
+}
+
+/*
+ * decodeRow64 converts width pixels from src into 16-bit big-endian RGBA in
+ * dst, the layout of image.RGBA64 and image.NRGBA64.
+ */
+func (format pixelFormat) decodeRow64(dst, src []byte, width int) {
+	for x := 0; x < width; x++ {
+		pixel := format.pixel(src, x)
+		var a uint16 = 0xFFFF
+		if format.hasAlpha() {
+			a = format.alpha.value16(pixel)
+		}
+		r := format.red.value16(pixel)
+		g := format.green.value16(pixel)
+		b := format.blue.value16(pixel)
+
+		d := dst[x * 8:x * 8 + 8]
+		d[0], d[1] = uint8(r >> 8), uint8(r)
+		d[2], d[3] = uint8(g >> 8), uint8(g)
+		d[4], d[5] = uint8(b >> 8), uint8(b)
+		d[6], d[7] = uint8(a >> 8), uint8(a)
+	}
+}
+
+/*
+ * premultiplyRow premultiplies width 8-bit RGBA pixels in place, rounding
+ * like color.RGBAModel does.
+ */
+func premultiplyRow(row []byte, width int) {
+	for x := 0; x < width; x++ {
+		p := row[x * 4:x * 4 + 4]
+		a := uint32(p[3]) * 0x101
+		if a == 0xFFFF {
+			continue
+		}
+		for c := 0; c < 3; c++ {
+			p[c] = uint8(uint32(p[c]) * 0x101 * a / 0xFFFF >> 8)
+		}
+	}
+}
+
+// unpremultiplyRow is the inverse of premultiplyRow, like color.NRGBAModel.
+func unpremultiplyRow(row []byte, width int) {
+	for x := 0; x < width; x++ {
+		p := row[x * 4:x * 4 + 4]
+		a := uint32(p[3]) * 0x101
+		switch a {
+			case 0xFFFF:
+				continue
+			case 0:
+				p[0], p[1], p[2] = 0, 0, 0
+				continue
+		}
+		for c := 0; c < 3; c++ {
+			p[c] = uint8(uint32(p[c]) * 0x101 * 0xFFFF / a >> 8)
+		}
+	}
+}
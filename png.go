@@ -0,0 +1,296 @@
+package egl
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+const pngSignature = "\x89PNG\r\n\x1a\n"
+
+// Compressed data is split into IDAT chunks of this size when streaming.
+const pngIDATSize = 1 << 16
+
+type pngChunk struct {
+	chunkType string
+	data []byte
+}
+
+func writePNGChunk(w io.Writer, chunk pngChunk) error {
+	var header [8]byte
+	binary.BigEndian.PutUint32(header[:4], uint32(len(chunk.data)))
+	copy(header[4:], chunk.chunkType)
+
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(chunk.data)
+	var footer [4]byte
+	binary.BigEndian.PutUint32(footer[:], crc.Sum32())
+
+	_, headerErr := w.Write(header[:])
+	if headerErr != nil {
+		return headerErr
+	}
+	_, dataErr := w.Write(chunk.data)
+	if dataErr != nil {
+		return dataErr
+	}
+	_, footerErr := w.Write(footer[:])
+	return footerErr
+}
+
+func gamaChunk(gamma uint32) pngChunk {
+	data := make([]byte, 4)
+	binary.BigEndian.PutUint32(data, gamma)
+	return pngChunk{"gAMA", data}
+}
+
+// cicpChunk takes ITU-T H.273 code points. Matrix coefficients are always
+// 0 (RGB) and the range is always full.
+func cicpChunk(primaries, transfer byte) pngChunk {
+	return pngChunk{"cICP", []byte{primaries, transfer, 0, 1}}
+}
+
+/*
+ * colorspaceChunks returns the chunks telling PNG readers how to interpret
+ * pixels in colorspace: sRGB and gAMA for sRGB, gAMA for linear, and cICP
+ * for the wide gamut spaces.
+ */
+func colorspaceChunks(colorspace Attrib) []pngChunk {
+	const (
+		primariesBT709 = 1
+		primariesBT2020 = 9
+		primariesDisplayP3 = 12
+		transferLinear = 8
+		transferSRGB = 13
+		transferPQ = 16
+	)
+
+	switch colorspace {
+		case GLColorspaceSRGB:
+			// perceptual intent, with the gAMA the PNG spec recommends
+			return []pngChunk{{"sRGB", []byte{0}}, gamaChunk(45455)}
+		case GLColorspaceLinear:
+			return []pngChunk{gamaChunk(100000)}
+		case GLColorspaceBT2020Linear:
+			return []pngChunk{cicpChunk(primariesBT2020, transferLinear)}
+		case GLColorspaceBT2020PQ:
+			return []pngChunk{cicpChunk(primariesBT2020, transferPQ)}
+		case GLColorspaceDisplayP3:
+			return []pngChunk{cicpChunk(primariesDisplayP3, transferSRGB)}
+		case GLColorspaceDisplayP3Linear:
+			return []pngChunk{cicpChunk(primariesDisplayP3, transferLinear)}
+		case GLColorspaceSCRGB:
+			return []pngChunk{cicpChunk(primariesBT709, transferSRGB)}
+		case GLColorspaceSCRGBLinear:
+			return []pngChunk{cicpChunk(primariesBT709, transferLinear)}
+	}
+	return nil
+}
+
+/*
+ * EncodePNG writes img as a PNG with chunks describing colorspace, one of
+ * the GLColorspace values, inserted after the header.
+ */
+func EncodePNG(w io.Writer, img image.Image, colorspace Attrib) error {
+	var buffer bytes.Buffer
+	encodeErr := png.Encode(&buffer, img)
+	if encodeErr != nil {
+		return encodeErr
+	}
+
+	encoded := buffer.Bytes()
+	// signature, then IHDR's length, type, 13 bytes of data and CRC
+	const ihdrEnd = len(pngSignature) + 8 + 13 + 4
+	if len(encoded) < ihdrEnd || string(encoded[len(pngSignature) + 4:len(pngSignature) + 8]) != "IHDR" {
+		return errors.New("image/png produced an unexpected header")
+	}
+
+	_, headerErr := w.Write(encoded[:ihdrEnd])
+	if headerErr != nil {
+		return headerErr
+	}
+	for _, chunk := range(colorspaceChunks(colorspace)) {
+		chunkErr := writePNGChunk(w, chunk)
+		if chunkErr != nil {
+			return chunkErr
+		}
+	}
+	_, restErr := w.Write(encoded[ihdrEnd:])
+	return restErr
+}
+
+/*
+ * idatWriter collects compressed image data and writes it out as IDAT
+ * chunks.
+ */
+type idatWriter struct {
+	w io.Writer
+	buffer []byte
+}
+
+func (idat *idatWriter) Write(p []byte) (int, error) {
+	written := len(p)
+	for len(p) > 0 {
+		n := pngIDATSize - len(idat.buffer)
+		if n > len(p) {
+			n = len(p)
+		}
+		idat.buffer = append(idat.buffer, p[:n]...)
+		p = p[n:]
+		if len(idat.buffer) == pngIDATSize {
+			flushErr := idat.flush()
+			if flushErr != nil {
+				return 0, flushErr
+			}
+		}
+	}
+	return written, nil
+}
+
+func (idat *idatWriter) flush() error {
+	if len(idat.buffer) == 0 {
+		return nil
+	}
+	chunkErr := writePNGChunk(idat.w, pngChunk{"IDAT", idat.buffer})
+	idat.buffer = idat.buffer[:0]
+	return chunkErr
+}
+
+/*
+ * pngStreamEncoder writes a non-interlaced RGBA PNG one row at a time, so
+ * images larger than memory can be encoded. Rows are unfiltered.
+ */
+type pngStreamEncoder struct {
+	w io.Writer
+	idat *idatWriter
+	compressor *zlib.Writer
+	width, height int
+	deep bool
+	row []byte
+	rowsWritten int
+}
+
+/*
+ * newPNGStreamEncoder writes the PNG header for a width by height image
+ * with 8 or, if deep, 16 bits per component, followed by chunks describing
+ * colorspace.
+ */
+func newPNGStreamEncoder(w io.Writer, width, height int, deep bool, colorspace Attrib) (*pngStreamEncoder, error) {
+	bytesPerPixel := 4
+	var bitDepth byte = 8
+	if deep {
+		bytesPerPixel = 8
+		bitDepth = 16
+	}
+
+	_, signatureErr := io.WriteString(w, pngSignature)
+	if signatureErr != nil {
+		return nil, signatureErr
+	}
+	header := make([]byte, 13)
+	binary.BigEndian.PutUint32(header[0:4], uint32(width))
+	binary.BigEndian.PutUint32(header[4:8], uint32(height))
+	header[8] = bitDepth
+	header[9] = 6 // truecolor with alpha
+	headerErr := writePNGChunk(w, pngChunk{"IHDR", header})
+	if headerErr != nil {
+		return nil, headerErr
+	}
+	for _, chunk := range(colorspaceChunks(colorspace)) {
+		chunkErr := writePNGChunk(w, chunk)
+		if chunkErr != nil {
+			return nil, chunkErr
+		}
+	}
+
+	encoder := new(pngStreamEncoder)
+	encoder.w = w
+	encoder.idat = &idatWriter{w: w}
+	encoder.compressor = zlib.NewWriter(encoder.idat)
+	encoder.width = width
+	encoder.height = height
+	encoder.deep = deep
+	// one filter type byte, then the pixels
+	encoder.row = make([]byte, 1 + width * bytesPerPixel)
+	return encoder, nil
+}
+
+// writeRow encodes row y of img, which must be at least the image's width
+// starting at img.Bounds().Min.X.
+func (encoder *pngStreamEncoder) writeRow(img image.Image, y int) error {
+	if encoder.rowsWritten >= encoder.height {
+		return errors.New("too many rows written to PNG")
+	}
+
+	pixels := encoder.row[1:]
+	minX := img.Bounds().Min.X
+	switch source := img.(type) {
+		case *image.NRGBA:
+			if !encoder.deep {
+				offset := source.PixOffset(minX, y)
+				copy(pixels, source.Pix[offset:offset + len(pixels)])
+				break
+			}
+			encoder.convertRow(img, y)
+		case *image.NRGBA64:
+			if encoder.deep {
+				offset := source.PixOffset(minX, y)
+				copy(pixels, source.Pix[offset:offset + len(pixels)])
+				break
+			}
+			encoder.convertRow(img, y)
+		default:
+			encoder.convertRow(img, y)
+	}
+
+	_, writeErr := encoder.compressor.Write(encoder.row)
+	if writeErr != nil {
+		return writeErr
+	}
+	encoder.rowsWritten++
+	return nil
+}
+
+// convertRow fills the row buffer from any image, unpremultiplying alpha.
+func (encoder *pngStreamEncoder) convertRow(img image.Image, y int) {
+	pixels := encoder.row[1:]
+	minX := img.Bounds().Min.X
+	for x := 0; x < encoder.width; x++ {
+		c := img.At(minX + x, y)
+		if encoder.deep {
+			n := color.NRGBA64Model.Convert(c).(color.NRGBA64)
+			d := pixels[x * 8:x * 8 + 8]
+			d[0], d[1] = uint8(n.R >> 8), uint8(n.R)
+			d[2], d[3] = uint8(n.G >> 8), uint8(n.G)
+			d[4], d[5] = uint8(n.B >> 8), uint8(n.B)
+			d[6], d[7] = uint8(n.A >> 8), uint8(n.A)
+		} else {
+			n := color.NRGBAModel.Convert(c).(color.NRGBA)
+			d := pixels[x * 4:x * 4 + 4]
+			d[0], d[1], d[2], d[3] = n.R, n.G, n.B, n.A
+		}
+	}
+}
+
+// close finishes the compressed data and writes the trailing IEND chunk.
+func (encoder *pngStreamEncoder) close() error {
+	if encoder.rowsWritten != encoder.height {
+		return errors.New("PNG closed before all rows were written")
+	}
+	closeErr := encoder.compressor.Close()
+	if closeErr != nil {
+		return closeErr
+	}
+	flushErr := encoder.idat.flush()
+	if flushErr != nil {
+		return flushErr
+	}
+	return writePNGChunk(encoder.w, pngChunk{"IEND", nil})
+}
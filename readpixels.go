@@ -0,0 +1,402 @@
+package egl
+
+/*
+#cgo pkg-config: egl
+
+#include <EGL/egl.h>
+
+// GL types are spelled out so this file doesn't need the GLES headers.
+typedef void (*readPixelsFunc)(int x, int y, int width, int height, unsigned int format, unsigned int type, void *pixels);
+typedef void (*pixelStoreiFunc)(unsigned int name, int param);
+typedef unsigned int (*getErrorFunc)(void);
+typedef void (*getIntegervFunc)(unsigned int name, int *data);
+
+static void callReadPixels(void *function, int x, int y, int width, int height, unsigned int format, unsigned int type, void *pixels) {
+	((readPixelsFunc)function)(x, y, width, height, format, type, pixels);
+}
+
+static void callPixelStorei(void *function, unsigned int name, int param) {
+	((pixelStoreiFunc)function)(name, param);
+}
+
+static unsigned int callGetError(void *function) {
+	return ((getErrorFunc)function)();
+}
+
+static int callGetInteger(void *function, unsigned int name) {
+	int value = 0;
+	((getIntegervFunc)function)(name, &value);
+	return value;
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"unsafe"
+)
+
+// GL enums used for readback
+const (
+	glNoError = 0
+	glRGBA = 0x1908
+	glUnsignedByte = 0x1401
+	glUnsignedShort = 0x1403
+	glFloat = 0x1406
+	glUnsignedInt2101010Rev = 0x8368
+	glPackAlignment = 0x0D05
+	glImplementationColorReadType = 0x8B9A
+	glImplementationColorReadFormat = 0x8B9B
+)
+
+type glReadFuncs struct {
+	readPixels unsafe.Pointer
+	pixelStorei unsafe.Pointer
+	getError unsafe.Pointer
+	getIntegerv unsafe.Pointer
+}
+
+func resolveGLReadFuncs(display *Display) (glReadFuncs, error) {
+	if !display.resolvesCoreGL() {
+		return glReadFuncs{}, errors.New("reading pixels requires EGL 1.5 or EGL_KHR_get_all_proc_addresses")
+	}
+	funcs := glReadFuncs{
+		readPixels: procAddress("glReadPixels"),
+		pixelStorei: procAddress("glPixelStorei"),
+		getError: procAddress("glGetError"),
+		getIntegerv: procAddress("glGetIntegerv"),
+	}
+	if funcs.readPixels == nil || funcs.pixelStorei == nil || funcs.getError == nil || funcs.getIntegerv == nil {
+		return funcs, errors.New("eglGetProcAddress could not resolve glReadPixels, glPixelStorei, glGetError and glGetIntegerv")
+	}
+	return funcs, nil
+}
+
+// clearErrors drops stale errors, so they aren't blamed on the next call.
+func (funcs glReadFuncs) clearErrors() {
+	for i := 0; i < 8; i++ {
+		if C.callGetError(funcs.getError) == glNoError {
+			break
+		}
+	}
+}
+
+func (funcs glReadFuncs) checkError(operation string) error {
+	glErr := C.callGetError(funcs.getError)
+	if glErr != glNoError {
+		return fmt.Errorf("%s failed with GL error 0x%X", operation, glErr)
+	}
+	return nil
+}
+
+/*
+ * readKind returns the component format glReadPixels can deliver for a
+ * surface read back as kind. GLES only guarantees GL_UNSIGNED_BYTE, plus
+ * one format and type pair the implementation reports for the read buffer,
+ * so 10 and 16-bit reads fall back to 8 bits unless that pair matches.
+ * Floating point buffers always allow GL_FLOAT, and desktop GL allows any
+ * type.
+ */
+func (funcs glReadFuncs) readKind(kind int, desktop bool) int {
+	if kind == readback8 || kind == readbackFloat || desktop {
+		return kind
+	}
+	format := C.callGetInteger(funcs.getIntegerv, glImplementationColorReadFormat)
+	glType := C.callGetInteger(funcs.getIntegerv, glImplementationColorReadType)
+	queryErr := funcs.checkError("glGetIntegerv")
+	wantType, _ := readbackGLType(kind)
+	if queryErr != nil || format != glRGBA || C.uint(glType) != wantType {
+		return readback8
+	}
+	return kind
+}
+
+// readbackGLType returns the glReadPixels type for kind and its pixel size.
+func readbackGLType(kind int) (C.uint, int) {
+	switch kind {
+		case readback10:
+			return glUnsignedInt2101010Rev, 4
+		case readback16:
+			return glUnsignedShort, 8
+		case readbackFloat:
+			return glFloat, 16
+	}
+	return glUnsignedByte, 4
+}
+
+/*
+ * currentClientType returns the client API of the context current on the
+ * calling thread, which needn't be the API the thread has bound.
+ */
+func (display *Display) currentClientType() int {
+	var clientType C.EGLint
+	C.eglQueryContext(display.eglDisplay, C.eglGetCurrentContext(), C.EGL_CONTEXT_CLIENT_TYPE, &clientType)
+	return int(clientType)
+}
+
+func (surface *Surface) isCurrentRead() bool {
+	return C.eglGetCurrentSurface(Read) == surface.eglSurface
+}
+
+// Readback component formats, chosen from the surface's config.
+const (
+	readback8 = iota
+	readback10
+	readback16
+	readbackFloat
+)
+
+// floatComponents reports whether the surface's config is floating point.
+func (surface *Surface) floatComponents() bool {
+	display := surface.Display
+	if !display.HasExtension("EGL_EXT_pixel_format_float") {
+		return false
+	}
+	componentType, typeErr := display.GetConfigAttrib(surface.config, ColorComponentType)
+	return typeErr == nil && componentType == ColorComponentTypeFloat
+}
+
+/*
+ * readbackFormat picks the component format to read the surface in, so
+ * 10-bit, 16-bit and floating point configs keep their precision.
+ */
+func (surface *Surface) readbackFormat() (int, error) {
+	display := surface.Display
+	if surface.floatComponents() {
+		return readbackFloat, nil
+	}
+
+	redSize, sizeErr := display.GetConfigAttrib(surface.config, RedSize)
+	if sizeErr != nil {
+		return readback8, sizeErr
+	}
+	switch {
+		case redSize <= 8:
+			return readback8, nil
+		case redSize == 10:
+			return readback10, nil
+	}
+	return readback16, nil
+}
+
+func newReadbackImage(kind int, bounds image.Rectangle, premultiplied bool) draw.Image {
+	switch kind {
+		case readbackFloat:
+			return NewFloatRGBA(bounds, premultiplied)
+		case readback10, readback16:
+			if premultiplied {
+				return image.NewRGBA64(bounds)
+			}
+			return image.NewNRGBA64(bounds)
+	}
+	if premultiplied {
+		return image.NewRGBA(bounds)
+	}
+	return image.NewNRGBA(bounds)
+}
+
+/*
+ * ReadPixels reads the surface with glReadPixels from the context current
+ * on the calling thread, which must have this surface as its read surface.
+ * Unlike CopyBuffers it needs no X server, so it works with pbuffers on
+ * surfaceless and device displays. The image has Go's top-left origin, and
+ * is empty for a surface with no pixels, such as a pbuffer created without
+ * a size.
+ *
+ * The image type follows the config: *image.RGBA or *image.NRGBA for 8-bit
+ * components, *image.RGBA64 or *image.NRGBA64 for 10 and 16-bit ones, and
+ * *FloatRGBA for EGL_EXT_pixel_format_float configs, including half floats.
+ */
+func (surface *Surface) ReadPixels() (image.Image, error) {
+	bounds, boundsErr := surface.bounds()
+	if boundsErr != nil {
+		return nil, boundsErr
+	}
+
+	premultiplied, alphaErr := surface.alphaPremultiplied()
+	if alphaErr != nil {
+		return nil, alphaErr
+	}
+	kind, kindErr := surface.readbackFormat()
+	if kindErr != nil {
+		return nil, kindErr
+	}
+
+	goImage := newReadbackImage(kind, bounds, premultiplied)
+	readErr := surface.readPixelsInto(goImage, bounds)
+	if readErr != nil {
+		return nil, readErr
+	}
+	return goImage, nil
+}
+
+/*
+ * readPixelsInto reads rect, which must lie within the surface, into the
+ * same pixels of dst. GL's rows run bottom to top, so they are flipped.
+ */
+func (surface *Surface) readPixelsInto(dst draw.Image, rect image.Rectangle) error {
+	premultiplied, alphaErr := surface.alphaPremultiplied()
+	if alphaErr != nil {
+		return alphaErr
+	}
+	kind, kindErr := surface.readbackFormat()
+	if kindErr != nil {
+		return kindErr
+	}
+
+	if !surface.isCurrentRead() {
+		return errors.New("cannot read pixels, surface is not the current read surface on this thread")
+	}
+	if rect.Empty() {
+		return nil
+	}
+	funcs, funcsErr := resolveGLReadFuncs(surface.Display)
+	if funcsErr != nil {
+		return funcsErr
+	}
+	bounds, boundsErr := surface.bounds()
+	if boundsErr != nil {
+		return boundsErr
+	}
+
+	funcs.clearErrors()
+	glKind := funcs.readKind(kind, surface.Display.currentClientType() == OpenGLAPI)
+	glType, bytesPerPixel := readbackGLType(glKind)
+
+	// the buffer ends with room for one row converted to 16 bits
+	width, height := rect.Dx(), rect.Dy()
+	rowBytes := width * bytesPerPixel
+	pixelBytes := rowBytes * height
+	if len(surface.rowBuffer) < pixelBytes + width * 8 {
+		surface.rowBuffer = make([]byte, pixelBytes + width * 8)
+	}
+	pixels := surface.rowBuffer[:pixelBytes]
+	scratch := surface.rowBuffer[pixelBytes:pixelBytes + width * 8]
+
+	// rows are packed, and the caller's alignment is put back afterward
+	alignment := C.callGetInteger(funcs.getIntegerv, glPackAlignment)
+	C.callPixelStorei(funcs.pixelStorei, glPackAlignment, 1)
+	defer C.callPixelStorei(funcs.pixelStorei, glPackAlignment, alignment)
+	C.callReadPixels(
+		funcs.readPixels,
+		C.int(rect.Min.X),
+		C.int(bounds.Max.Y - rect.Max.Y),
+		C.int(width),
+		C.int(height),
+		glRGBA,
+		glType,
+		unsafe.Pointer(&pixels[0]))
+	readErr := funcs.checkError("glReadPixels")
+	if readErr != nil {
+		return readErr
+	}
+
+	for y := 0; y < height; y++ {
+		// bottom row first
+		sourceY := height - 1 - y
+		raw := pixels[sourceY * rowBytes:sourceY * rowBytes + rowBytes]
+		rowRect := image.Rect(rect.Min.X, rect.Min.Y + y, rect.Max.X, rect.Min.Y + y + 1)
+		copyRow(dst, rowRect, glRowImage(kind, glKind, raw, scratch, rowRect, premultiplied))
+	}
+	return nil
+}
+
+/*
+ * glRowImage wraps one row read from glReadPixels as glKind in the image
+ * type kind reads into. Packed and 8-bit rows read for deeper kinds are
+ * converted into scratch, which holds one row of 16-bit pixels.
+ */
+func glRowImage(kind, glKind int, raw, scratch []byte, rowRect image.Rectangle, premultiplied bool) image.Image {
+	width := rowRect.Dx()
+	if width == 0 {
+		return image.NewRGBA(rowRect)
+	}
+	switch glKind {
+		case readback10:
+			packed := unsafe.Slice((*uint32)(unsafe.Pointer(&raw[0])), width)
+			pix := scratch
+			for x, value := range(packed) {
+				r := uint16(value & 0x3FF)
+				g := uint16(value >> 10 & 0x3FF)
+				b := uint16(value >> 20 & 0x3FF)
+				a := uint16(value >> 30) * 0x5555
+				r, g, b = r << 6 | r >> 4, g << 6 | g >> 4, b << 6 | b >> 4
+				d := pix[x * 8:x * 8 + 8]
+				d[0], d[1], d[2], d[3] = uint8(r >> 8), uint8(r), uint8(g >> 8), uint8(g)
+				d[4], d[5], d[6], d[7] = uint8(b >> 8), uint8(b), uint8(a >> 8), uint8(a)
+			}
+			return rgba64Row(pix, rowRect, premultiplied)
+		case readback16:
+			components := unsafe.Slice((*uint16)(unsafe.Pointer(&raw[0])), width * 4)
+			pix := scratch
+			for i, value := range(components) {
+				pix[i * 2], pix[i * 2 + 1] = uint8(value >> 8), uint8(value)
+			}
+			return rgba64Row(pix, rowRect, premultiplied)
+		case readbackFloat:
+			components := unsafe.Slice((*float32)(unsafe.Pointer(&raw[0])), width * 4)
+			return &FloatRGBA{
+				Pix: components,
+				Stride: width * 4,
+				Rect: rowRect,
+				Premultiplied: premultiplied,
+			}
+	}
+	if kind == readback10 || kind == readback16 {
+		// the driver could only read 8 bits
+		for i, value := range(raw) {
+			scratch[i * 2], scratch[i * 2 + 1] = value, value
+		}
+		return rgba64Row(scratch, rowRect, premultiplied)
+	}
+	if premultiplied {
+		return &image.RGBA{Pix: raw, Stride: width * 4, Rect: rowRect}
+	}
+	return &image.NRGBA{Pix: raw, Stride: width * 4, Rect: rowRect}
+}
+
+func rgba64Row(pix []byte, rowRect image.Rectangle, premultiplied bool) image.Image {
+	if premultiplied {
+		return &image.RGBA64{Pix: pix, Stride: len(pix), Rect: rowRect}
+	}
+	return &image.NRGBA64{Pix: pix, Stride: len(pix), Rect: rowRect}
+}
+
+/*
+ * copyRow copies a one-row image into the same row of dst, copying pixel
+ * memory directly when both are the same type.
+ */
+func copyRow(dst draw.Image, rowRect image.Rectangle, row image.Image) {
+	x, y := rowRect.Min.X, rowRect.Min.Y
+	switch source := row.(type) {
+		case *image.RGBA:
+			if d, ok := dst.(*image.RGBA); ok {
+				copy(d.Pix[d.PixOffset(x, y):], source.Pix)
+				return
+			}
+		case *image.NRGBA:
+			if d, ok := dst.(*image.NRGBA); ok {
+				copy(d.Pix[d.PixOffset(x, y):], source.Pix)
+				return
+			}
+		case *image.RGBA64:
+			if d, ok := dst.(*image.RGBA64); ok {
+				copy(d.Pix[d.PixOffset(x, y):], source.Pix)
+				return
+			}
+		case *image.NRGBA64:
+			if d, ok := dst.(*image.NRGBA64); ok {
+				copy(d.Pix[d.PixOffset(x, y):], source.Pix)
+				return
+			}
+		case *FloatRGBA:
+			if d, ok := dst.(*FloatRGBA); ok && d.Premultiplied == source.Premultiplied {
+				copy(d.Pix[d.PixOffset(x, y):], source.Pix)
+				return
+			}
+	}
+	draw.Draw(dst, rowRect, row, rowRect.Min, draw.Src)
+}
@@ -0,0 +1,96 @@
+package egl
+
+import (
+	"image"
+	"runtime"
+	"testing"
+)
+
+/*
+ * openTestSurfacelessDisplay opens and initializes Mesa's surfaceless
+ * display, skipping the test when there is none. It is closed when the
+ * test ends.
+ */
+func openTestSurfacelessDisplay(tb testing.TB) *Display {
+	display, displayErr := OpenSurfacelessDisplay()
+	if displayErr != nil {
+		tb.Skip(displayErr)
+	}
+	initErr := display.Initialize()
+	if initErr != nil {
+		display.Close()
+		tb.Skip(initErr)
+	}
+	tb.Cleanup(func() { display.Close() })
+	return display
+}
+
+/*
+ * currentTestPbuffer makes a GLES context current with a pbuffer, sized
+ * by attribList, as its draw and read surface.
+ */
+func currentTestPbuffer(t *testing.T, attribList []Attrib) (*Surface, *GL) {
+	runtime.LockOSThread()
+	t.Cleanup(runtime.UnlockOSThread)
+	display := openTestSurfacelessDisplay(t)
+	BindAPI(OpenGLESAPI)
+	config := chooseTestConfig(t, display, PbufferBit)
+
+	surface, surfaceErr := display.CreatePbufferSurface(config, attribList)
+	if surfaceErr != nil {
+		t.Fatal(surfaceErr)
+	}
+	t.Cleanup(func() { surface.Destroy() })
+	context, contextErr := display.CreateContext(config, nil, []Attrib{ContextClientVersion, 2, None})
+	if contextErr != nil {
+		t.Skip(contextErr)
+	}
+	t.Cleanup(func() { context.Destroy() })
+	makeErr := context.MakeCurrent(surface, surface)
+	if makeErr != nil {
+		t.Skip(makeErr)
+	}
+	t.Cleanup(func() { display.ReleaseCurrentContext() })
+
+	gl, glErr := context.GL()
+	if glErr != nil {
+		t.Fatal(glErr)
+	}
+	return surface, gl
+}
+
+func TestReadPixels(t *testing.T) {
+	tests := []struct {
+		name string
+		attribList []Attrib
+		bounds image.Rectangle
+	}{
+		// EGL's default size is 0x0
+		{"empty", []Attrib{None}, image.Rectangle{}},
+		{"7x3", []Attrib{Width, 7, Height, 3, None}, image.Rect(0, 0, 7, 3)},
+	}
+	for _, test := range(tests) {
+		t.Run(test.name, func(t *testing.T) {
+			surface, gl := currentTestPbuffer(t, test.attribList)
+			gl.PixelStorei(GLPackAlignment, 8)
+
+			pixels, readErr := surface.ReadPixels()
+			if readErr != nil {
+				t.Fatal(readErr)
+			}
+			if pixels.Bounds() != test.bounds {
+				t.Errorf("read back %v, want %v", pixels.Bounds(), test.bounds)
+			}
+			_, copyErr := surface.CopyBuffers()
+			if copyErr != nil {
+				t.Error(copyErr)
+			}
+
+			var alignment [1]int32
+			gl.GetIntegerv(GLPackAlignment, alignment[:])
+			if alignment[0] != 8 {
+				t.Errorf("pack alignment is %d after reading, want 8", alignment[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package egl
+
+import (
+	"errors"
+)
+
+const (
+	resizablePbuffer = iota
+	resizablePixmap
+	resizableWindow
+)
+
+/*
+ * ResizableSurface wraps a Surface whose size can change. Pbuffers and
+ * pixmaps have a fixed size, so resizing one destroys the underlying Surface
+ * and creates a new one with the same config and attributes, including any
+ * set later with SetAttrib or SetSwapInterval. Window surfaces
+ * follow their X window, so resizing one only resizes the window.
+ *
+ * Surface may return a different *Surface after every Resize or HandleEvent,
+ * so callers shouldn't hold on to it.
+ */
+type ResizableSurface struct {
+	Display *Display
+	config Config
+	attribList []Attrib
+	kind int
+	window *Window
+	surface *Surface
+	context *Context
+	width, height int
+}
+
+func (display *Display) CreateResizablePbufferSurface(config Config, attribList []Attrib, width, height int) (*ResizableSurface, error) {
+	resizable := newResizableSurface(display, config, attribList, resizablePbuffer)
+	resizeErr := resizable.Resize(width, height)
+	if resizeErr != nil {
+		return nil, resizeErr
+	}
+	return resizable, nil
+}
+
+func (display *Display) CreateResizablePixmapSurface(config Config, attribList []Attrib, width, height int) (*ResizableSurface, error) {
+	resizable := newResizableSurface(display, config, attribList, resizablePixmap)
+	resizeErr := resizable.Resize(width, height)
+	if resizeErr != nil {
+		return nil, resizeErr
+	}
+	return resizable, nil
+}
+
+/*
+ * NewResizableWindowSurface tracks the size of window's surface. The surface
+ * still belongs to window and is destroyed by window.Destroy.
+ */
+func NewResizableWindowSurface(window *Window) *ResizableSurface {
+	var noConfig Config
+	resizable := newResizableSurface(window.Surface.Display, noConfig, nil, resizableWindow)
+	resizable.window = window
+	resizable.surface = window.Surface
+	resizable.width = window.width
+	resizable.height = window.height
+	return resizable
+}
+
+func newResizableSurface(display *Display, config Config, attribList []Attrib, kind int) *ResizableSurface {
+	resizable := new(ResizableSurface)
+	resizable.Display = display
+	resizable.config = config
+	resizable.attribList = stripSizeAttribs(attribList)
+	resizable.kind = kind
+	return resizable
+}
+
+// stripSizeAttribs copies attribList without Width, Height or the None
+// terminator, so a new size can be appended.
+func stripSizeAttribs(attribList []Attrib) []Attrib {
+	var stripped []Attrib
+	for i := 0; i + 1 < len(attribList); i += 2 {
+		name := attribList[i]
+		if name == None {
+			break
+		}
+		if name == Width || name == Height {
+			continue
+		}
+		stripped = append(stripped, name, attribList[i + 1])
+	}
+	return stripped
+}
+
+func (resizable *ResizableSurface) Surface() *Surface {
+	return resizable.surface
+}
+
+func (resizable *ResizableSurface) Size() (width, height int) {
+	return resizable.width, resizable.height
+}
+
+/*
+ * MakeCurrent binds context to the surface for both drawing and reading, and
+ * remembers it so the replacement surface can be made current after a
+ * resize.
+ */
+func (resizable *ResizableSurface) MakeCurrent(context *Context) error {
+	makeErr := context.MakeCurrent(resizable.surface, resizable.surface)
+	if makeErr != nil {
+		return makeErr
+	}
+	resizable.context = context
+	return nil
+}
+
+/*
+ * Resize changes the surface to width by height. Pbuffer sizes are clamped
+ * to the config's MaxPbufferWidth, MaxPbufferHeight and MaxPbufferPixels, so
+ * check Size afterward. If creating the new surface fails, the old one is
+ * kept.
+ */
+func (resizable *ResizableSurface) Resize(width, height int) error {
+	if width <= 0 || height <= 0 {
+		return errors.New("surface width and height must be positive")
+	}
+
+	display := resizable.Display
+	if resizable.kind == resizableWindow {
+		if width == resizable.width && height == resizable.height {
+			return nil
+		}
+		resizable.window.Resize(width, height)
+		resizable.width, resizable.height = width, height
+		return nil
+	}
+
+	if resizable.kind == resizablePbuffer {
+		var clampErr error
+		width, height, clampErr = display.clampPbufferSize(resizable.config, width, height)
+		if clampErr != nil {
+			return clampErr
+		}
+	}
+	if resizable.surface != nil && width == resizable.width && height == resizable.height {
+		return nil
+	}
+
+	var surface *Surface
+	var createErr error
+	switch resizable.kind {
+		case resizablePbuffer:
+			attribList := append([]Attrib(nil), resizable.attribList...)
+			attribList = append(attribList, Width, Attrib(width), Height, Attrib(height), None)
+			surface, createErr = display.CreatePbufferSurface(resizable.config, attribList)
+		case resizablePixmap:
+			var attribList []Attrib
+			if resizable.attribList != nil {
+				attribList = append([]Attrib(nil), resizable.attribList...)
+				attribList = append(attribList, None)
+			}
+			surface, createErr = display.CreatePixmapSurface(resizable.config, attribList, width, height)
+	}
+	if createErr != nil {
+		return createErr
+	}
+
+	if resizable.context != nil {
+		makeErr := resizable.context.MakeCurrent(surface, surface)
+		if makeErr != nil {
+			surface.Destroy()
+			return makeErr
+		}
+	}
+
+	var result error
+	if resizable.surface != nil {
+		copyErr := surface.copySettings(resizable.surface)
+		if copyErr != nil {
+			if resizable.context != nil {
+				resizable.context.MakeCurrent(resizable.surface, resizable.surface)
+			}
+			surface.Destroy()
+			return copyErr
+		}
+		result = resizable.surface.Destroy()
+	}
+	resizable.surface = surface
+	resizable.width, resizable.height = width, height
+
+	return result
+}
+
+/*
+ * clampPbufferSize shrinks width and height to fit config's MaxPbufferWidth,
+ * MaxPbufferHeight and MaxPbufferPixels.
+ */
+func (display *Display) clampPbufferSize(config Config, width, height int) (int, int, error) {
+	maxWidth, widthErr := display.GetConfigAttrib(config, MaxPbufferWidth)
+	if widthErr != nil {
+		return 0, 0, widthErr
+	}
+	maxHeight, heightErr := display.GetConfigAttrib(config, MaxPbufferHeight)
+	if heightErr != nil {
+		return 0, 0, heightErr
+	}
+	maxPixels, pixelsErr := display.GetConfigAttrib(config, MaxPbufferPixels)
+	if pixelsErr != nil {
+		return 0, 0, pixelsErr
+	}
+
+	if maxWidth > 0 && width > int(maxWidth) {
+		width = int(maxWidth)
+	}
+	if maxHeight > 0 && height > int(maxHeight) {
+		height = int(maxHeight)
+	}
+	if maxPixels > 0 && width * height > int(maxPixels) {
+		height = int(maxPixels) / width
+		if height <= 0 {
+			return 0, 0, errors.New("config's MaxPbufferPixels is smaller than one row")
+		}
+	}
+
+	return width, height, nil
+}
+
+/*
+ * HandleEvent updates the tracked size from a window's ResizeEvent. Other
+ * events are ignored, so every event from Window.Events can be passed in.
+ */
+func (resizable *ResizableSurface) HandleEvent(event Event) error {
+	if event.Type != ResizeEvent {
+		return nil
+	}
+	if resizable.kind == resizableWindow {
+		// the window surface follows the X window by itself
+		resizable.width, resizable.height = event.Width, event.Height
+		return nil
+	}
+	return resizable.Resize(event.Width, event.Height)
+}
+
+/*
+ * Destroy destroys the current pbuffer or pixmap surface. Window surfaces
+ * are left for Window.Destroy.
+ */
+func (resizable *ResizableSurface) Destroy() error {
+	if resizable.kind == resizableWindow || resizable.surface == nil {
+		return nil
+	}
+	result := resizable.surface.Destroy()
+	resizable.surface = nil
+	return result
+}
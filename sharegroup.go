@@ -0,0 +1,115 @@
+package egl
+
+import (
+	"sync"
+)
+
+/*
+ * ShareGroup creates contexts that share textures, buffers and other GL
+ * objects, and keeps track of them. EGL only shares objects between
+ * contexts of the same client API, so members created for different APIs
+ * form separate groups of sharers within the ShareGroup. Members can be
+ * destroyed in any order; new members share with the oldest surviving one.
+ */
+type ShareGroup struct {
+	Display *Display
+
+	lock sync.Mutex
+	members []*Context // in creation order
+}
+
+func NewShareGroup(display *Display) *ShareGroup {
+	group := new(ShareGroup)
+	group.Display = display
+	return group
+}
+
+/*
+ * CreateContext creates a member context for api (OpenGLESAPI, OpenGLAPI or
+ * OpenVGAPI) sharing objects with the group's existing members of that API.
+ * config may differ between members, as long as EGL considers the configs
+ * compatible. The calling thread's bound API is restored afterward.
+ */
+func (group *ShareGroup) CreateContext(config Config, api int, attribList []Attrib) (*Context, error) {
+	group.lock.Lock()
+	defer group.lock.Unlock()
+
+	var shareContext *Context
+	for _, member := range(group.members) {
+		if member.api == api {
+			shareContext = member
+			break
+		}
+	}
+
+	previousAPI := QueryAPI()
+	bindErr := BindAPI(api)
+	if bindErr != nil {
+		return nil, bindErr
+	}
+	context, createErr := group.Display.CreateContext(config, shareContext, attribList)
+	if previousAPI != api {
+		BindAPI(previousAPI)
+	}
+	if createErr != nil {
+		return nil, createErr
+	}
+
+	context.group = group
+	context.api = api
+	group.members = append(group.members, context)
+	return context, nil
+}
+
+// Members returns the group's live contexts, oldest first.
+func (group *ShareGroup) Members() []*Context {
+	group.lock.Lock()
+	defer group.lock.Unlock()
+	return append([]*Context(nil), group.members...)
+}
+
+// Contains reports whether context was created by the group and is alive.
+func (group *ShareGroup) Contains(context *Context) bool {
+	group.lock.Lock()
+	defer group.lock.Unlock()
+	for _, member := range(group.members) {
+		if member == context {
+			return true
+		}
+	}
+	return false
+}
+
+// remove forgets a member once EGL has destroyed it.
+func (group *ShareGroup) remove(context *Context) {
+	group.lock.Lock()
+	defer group.lock.Unlock()
+	for i, member := range(group.members) {
+		if member == context {
+			group.members = append(group.members[:i], group.members[i + 1:]...)
+			break
+		}
+	}
+}
+
+/*
+ * Destroy destroys every member, newest first, so the contexts others were
+ * created to share with go last. Members still current on some thread are
+ * destroyed by EGL once released. The first error is returned, but every
+ * member is still destroyed.
+ */
+func (group *ShareGroup) Destroy() error {
+	members := group.Members()
+	if members == nil {
+		return nil
+	}
+
+	var result error
+	for i := len(members) - 1; i >= 0; i-- {
+		destroyErr := members[i].Destroy()
+		if destroyErr != nil && result == nil {
+			result = destroyErr
+		}
+	}
+	return result
+}
@@ -0,0 +1,579 @@
+package egl
+
+/*
+#cgo pkg-config: egl x11 xext
+
+#include <EGL/egl.h>
+#include <X11/Xlib.h>
+#include <X11/Xutil.h>
+#include <X11/extensions/XShm.h>
+#include <sys/ipc.h>
+#include <sys/shm.h>
+#include <stdlib.h>
+#include <string.h>
+
+static int shmErrorOccurred;
+
+static int shmErrorHandler(Display *display, XErrorEvent *event) {
+	shmErrorOccurred = 1;
+	return 0;
+}
+
+// XShmAttach only fails asynchronously, for example when the server can't
+// reach our memory because it is remote, so errors are caught with a
+// temporary handler. Callers hold xErrorHandlerLock, since both the handler
+// and the flag are process-wide.
+static Bool attachShm(Display *display, XShmSegmentInfo *info) {
+	XSync(display, False);
+	shmErrorOccurred = 0;
+	XErrorHandler oldHandler = XSetErrorHandler(shmErrorHandler);
+	XShmAttach(display, info);
+	XSync(display, False);
+	XSetErrorHandler(oldHandler);
+	return !shmErrorOccurred;
+}
+
+static void destroyShmXImage(XImage *image) {
+	XDestroyImage(image);
+}
+
+static void *attachAddress(int id, int readOnly) {
+	return shmat(id, NULL, readOnly ? SHM_RDONLY : 0);
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+	"unsafe"
+)
+
+/*
+ * shmSegment is a SysV shared memory segment attached to both this process
+ * and the X server.
+ */
+type shmSegment struct {
+	xDisplay *C.Display
+	info *C.XShmSegmentInfo // C memory, since Xlib keeps pointers to it
+	size int
+}
+
+/*
+ * ShmAvailable reports whether the MIT-SHM extension can be used with this
+ * display. It can't when the server doesn't support it or is on another
+ * machine.
+ */
+func (display *Display) ShmAvailable() bool {
+	display.shmOnce.Do(func() {
+		if display.xDisplay == nil {
+			return
+		}
+		name := C.GoString(C.XDisplayString(display.xDisplay))
+		if !strings.HasPrefix(name, ":") && !strings.HasPrefix(name, "unix:") {
+			return
+		}
+		display.shmAvailable = C.XShmQueryExtension(display.xDisplay) != C.False
+	})
+	return display.shmAvailable
+}
+
+func newShmSegment(display *Display, size int) (*shmSegment, error) {
+	if !display.ShmAvailable() {
+		return nil, errors.New("MIT-SHM is not available on this display")
+	}
+
+	info := (*C.XShmSegmentInfo)(C.calloc(1, C.size_t(unsafe.Sizeof(C.XShmSegmentInfo{}))))
+	id, shmErr := C.shmget(C.IPC_PRIVATE, C.size_t(size), C.IPC_CREAT|0600)
+	if id < 0 {
+		C.free(unsafe.Pointer(info))
+		return nil, fmt.Errorf("shmget failed: %v", shmErr)
+	}
+	info.shmid = id
+
+	address, attachErr := C.attachAddress(id, 0)
+	if uintptr(address) == ^uintptr(0) {
+		C.shmctl(id, C.IPC_RMID, nil)
+		C.free(unsafe.Pointer(info))
+		return nil, fmt.Errorf("shmat failed: %v", attachErr)
+	}
+	info.shmaddr = (*C.char)(address)
+	info.readOnly = C.False
+
+	xErrorHandlerLock.Lock()
+	attached := C.attachShm(display.xDisplay, info)
+	xErrorHandlerLock.Unlock()
+	if attached == C.False {
+		C.shmdt(address)
+		C.shmctl(id, C.IPC_RMID, nil)
+		C.free(unsafe.Pointer(info))
+		return nil, errors.New("XShmAttach failed, the X server may be remote")
+	}
+	// the segment is freed once both we and the server detach
+	C.shmctl(id, C.IPC_RMID, nil)
+
+	segment := new(shmSegment)
+	segment.xDisplay = display.xDisplay
+	segment.info = info
+	segment.size = size
+	return segment, nil
+}
+
+func (segment *shmSegment) bytes() []byte {
+	return unsafe.Slice((*byte)(unsafe.Pointer(segment.info.shmaddr)), segment.size)
+}
+
+func (segment *shmSegment) destroy() {
+	C.XShmDetach(segment.xDisplay, segment.info)
+	C.XSync(segment.xDisplay, C.False)
+	C.shmdt(unsafe.Pointer(segment.info.shmaddr))
+	C.free(unsafe.Pointer(segment.info))
+	segment.info = nil
+}
+
+/*
+ * shmReadback is the shared memory image CopyBuffers and CopyBuffersInto
+ * read into once EnableShm has been called.
+ */
+type shmReadback struct {
+	segment *shmSegment
+	xImage *C.XImage
+	visual *C.Visual
+	depth C.uint
+	viewHeld bool
+}
+
+/*
+ * EnableShm makes CopyBuffers and CopyBuffersInto read pixels through an
+ * MIT-SHM segment instead of the X socket. If the extension can't be used
+ * an error is returned and readback keeps using XGetImage, so it is safe to
+ * ignore.
+ */
+func (surface *Surface) EnableShm() error {
+	if surface.shm != nil {
+		return nil
+	}
+	if !surface.Display.ShmAvailable() {
+		return errors.New("MIT-SHM is not available on this display")
+	}
+	surface.shm = new(shmReadback)
+	return nil
+}
+
+func (surface *Surface) DisableShm() {
+	if surface.shm == nil {
+		return
+	}
+	surface.shm.release()
+	surface.shm = nil
+}
+
+func (readback *shmReadback) release() {
+	if readback.xImage != nil {
+		C.destroyShmXImage(readback.xImage)
+		readback.xImage = nil
+	}
+	if readback.segment != nil {
+		readback.segment.destroy()
+		readback.segment = nil
+	}
+}
+
+/*
+ * readShmXImage fetches rect from drawable into the top-left corner of the
+ * surface's shared memory XImage.
+ */
+func (surface *Surface) readShmXImage(drawable C.Drawable, rect image.Rectangle) (*C.XImage, error) {
+	xImage, imageErr := surface.shmXImage(drawable, rect)
+	if imageErr != nil {
+		return nil, imageErr
+	}
+
+	allPlanes := C.ulong(C.XAllPlanes())
+	if C.XShmGetImage(surface.Display.xDisplay, drawable, xImage, C.int(rect.Min.X), C.int(rect.Min.Y), allPlanes) == C.False {
+		return nil, errors.New("XShmGetImage failed")
+	}
+	return xImage, nil
+}
+
+/*
+ * shmXImage returns the surface's shared memory XImage sized to rect,
+ * creating the segment at drawable's full size the first time.
+ */
+func (surface *Surface) shmXImage(drawable C.Drawable, rect image.Rectangle) (*C.XImage, error) {
+	readback := surface.shm
+	xDisplay := surface.Display.xDisplay
+
+	if readback.segment == nil {
+		var root C.Window
+		var x, y C.int
+		var width, height, border, depth C.uint
+		status := C.XGetGeometry(xDisplay, drawable, &root, &x, &y, &width, &height, &border, &depth)
+		if status == 0 {
+			return nil, errors.New("XGetGeometry failed")
+		}
+
+		// only the masks are read from the visual
+		readback.visual = surface.visual
+		if readback.visual == nil || C.uint(surface.depth) != depth {
+			readback.visual = C.XDefaultVisual(xDisplay, C.XDefaultScreen(xDisplay))
+		}
+		readback.depth = depth
+		fullImage := C.XShmCreateImage(xDisplay, readback.visual, depth, C.ZPixmap, nil, nil, width, height)
+		if fullImage == nil {
+			return nil, errors.New("XShmCreateImage failed")
+		}
+		size := int(fullImage.bytes_per_line) * int(height)
+		C.destroyShmXImage(fullImage)
+
+		segment, segmentErr := newShmSegment(surface.Display, size)
+		if segmentErr != nil {
+			return nil, segmentErr
+		}
+		readback.segment = segment
+	}
+
+	xImage := readback.xImage
+	if xImage == nil || int(xImage.width) != rect.Dx() || int(xImage.height) != rect.Dy() {
+		if xImage != nil {
+			C.destroyShmXImage(xImage)
+			readback.xImage = nil
+		}
+		xImage = C.XShmCreateImage(
+			xDisplay,
+			readback.visual,
+			readback.depth,
+			C.ZPixmap,
+			readback.segment.info.shmaddr,
+			readback.segment.info,
+			C.uint(rect.Dx()),
+			C.uint(rect.Dy()))
+		if xImage == nil {
+			return nil, errors.New("XShmCreateImage failed")
+		}
+		if int(xImage.bytes_per_line) * int(xImage.height) > readback.segment.size {
+			C.destroyShmXImage(xImage)
+			return nil, errors.New("requested rectangle doesn't fit in the shared memory segment")
+		}
+		readback.xImage = xImage
+	}
+	return xImage, nil
+}
+
+/*
+ * writeShmXImage uploads rows to rect of the surface's pixmap through the
+ * shared memory segment, creating it first if needed.
+ */
+func (surface *Surface) writeShmXImage(rows *image.RGBA, rect image.Rectangle) error {
+	xDisplay := surface.Display.xDisplay
+	drawable := C.Drawable(surface.xPixmap)
+
+	xImage, imageErr := surface.shmXImage(drawable, rect)
+	if imageErr != nil {
+		return imageErr
+	}
+	encodeErr := encodeXImage(xImage, rows)
+	if encodeErr != nil {
+		return encodeErr
+	}
+
+	gc := C.XCreateGC(xDisplay, drawable, 0, nil)
+	C.XShmPutImage(xDisplay, drawable, gc, xImage, 0, 0, C.int(rect.Min.X), C.int(rect.Min.Y), C.uint(rect.Dx()), C.uint(rect.Dy()), C.False)
+	C.XFreeGC(xDisplay, gc)
+	// the segment can't be reused until the server has read it
+	C.XSync(xDisplay, C.False)
+
+	return nil
+}
+
+/*
+ * ShmImage is a read-only view of a surface's pixels in shared memory, in
+ * the X visual's own format. Pix holds Stride bytes per row. The view stays
+ * valid until Release is called; meanwhile the surface's other readback
+ * falls back to XGetImage.
+ */
+type ShmImage struct {
+	Pix []byte
+	Stride int
+	Rect image.Rectangle
+
+	surface *Surface
+	format pixelFormat
+	premultiplied bool
+}
+
+/*
+ * ShmImageView reads the whole surface into shared memory and returns a
+ * view of it without copying. EnableShm must have been called.
+ */
+func (surface *Surface) ShmImageView() (*ShmImage, error) {
+	readback := surface.shm
+	if readback == nil {
+		return nil, errors.New("shared memory readback is not enabled, call EnableShm first")
+	}
+	if readback.viewHeld {
+		return nil, errors.New("previous ShmImage has not been released")
+	}
+
+	bounds, boundsErr := surface.bounds()
+	if boundsErr != nil {
+		return nil, boundsErr
+	}
+	xImage, format, readErr := surface.readXImage(bounds)
+	if readErr != nil {
+		return nil, readErr
+	}
+	if xImage != readback.xImage {
+		return nil, errors.New("shared memory readback failed")
+	}
+	premultiplied, alphaErr := surface.alphaPremultiplied()
+	if alphaErr != nil {
+		return nil, alphaErr
+	}
+
+	stride := int(xImage.bytes_per_line)
+	view := new(ShmImage)
+	view.Pix = readback.segment.bytes()[:stride * bounds.Dy()]
+	view.Stride = stride
+	view.Rect = bounds
+	view.surface = surface
+	view.format = format
+	view.premultiplied = premultiplied || !format.hasAlpha()
+	readback.viewHeld = true
+	return view, nil
+}
+
+// Release hands the shared memory back to the surface. Pix must not be
+// used afterward.
+func (view *ShmImage) Release() {
+	if view.surface == nil {
+		return
+	}
+	if view.surface.shm != nil {
+		view.surface.shm.viewHeld = false
+	}
+	view.surface = nil
+	view.Pix = nil
+}
+
+func (view *ShmImage) ColorModel() color.Model {
+	if view.premultiplied {
+		return color.RGBAModel
+	}
+	return color.NRGBAModel
+}
+
+func (view *ShmImage) Bounds() image.Rectangle {
+	return view.Rect
+}
+
+func (view *ShmImage) At(x, y int) color.Color {
+	if !(image.Point{x, y}.In(view.Rect)) || view.Pix == nil {
+		return color.RGBA{}
+	}
+	var p [4]byte
+	row := view.Pix[(y - view.Rect.Min.Y) * view.Stride:]
+	view.format.decodeRow(p[:], row[(x - view.Rect.Min.X) * view.format.bytesPerPixel():], 1)
+	if view.premultiplied {
+		return color.RGBA{p[0], p[1], p[2], p[3]}
+	}
+	return color.NRGBA{p[0], p[1], p[2], p[3]}
+}
+
+/*
+ * createShmPixmap creates a width by height pixmap whose pixels live in a
+ * new shared memory segment, laid out as a ZPixmap XImage of visual.
+ */
+func (display *Display) createShmPixmap(drawable C.Drawable, visual *C.Visual, depth, width, height int) (C.Pixmap, *shmSegment, error) {
+	xDisplay := display.xDisplay
+	if !display.ShmAvailable() {
+		return 0, nil, errors.New("MIT-SHM is not available on this display")
+	}
+	if C.XShmPixmapFormat(xDisplay) != C.ZPixmap {
+		return 0, nil, errors.New("X server does not support shared memory pixmaps")
+	}
+
+	layout := C.XShmCreateImage(xDisplay, visual, C.uint(depth), C.ZPixmap, nil, nil, C.uint(width), C.uint(height))
+	if layout == nil {
+		return 0, nil, errors.New("XShmCreateImage failed")
+	}
+	size := int(layout.bytes_per_line) * height
+	C.destroyShmXImage(layout)
+
+	segment, segmentErr := newShmSegment(display, size)
+	if segmentErr != nil {
+		return 0, nil, segmentErr
+	}
+	pixmap := C.XShmCreatePixmap(xDisplay, drawable, segment.info.shmaddr, segment.info, C.uint(width), C.uint(height), C.uint(depth))
+	if pixmap == 0 {
+		segment.destroy()
+		return 0, nil, errors.New("XShmCreatePixmap failed")
+	}
+	return pixmap, segment, nil
+}
+
+/*
+ * ShmPixmapInfo describes a shared memory pixmap so another process can
+ * read it with OpenShmFrameReader: the SysV segment id, the size, and the
+ * pixel layout, which is that of a ZPixmap XImage. It holds only plain
+ * values, so it can be sent in any encoding.
+ */
+type ShmPixmapInfo struct {
+	SegmentID int
+	Width, Height int
+	Stride int
+	BitsPerPixel int
+	Depth int
+	MSBFirst bool
+	RedMask, GreenMask, BlueMask uint32
+	Premultiplied bool
+}
+
+/*
+ * ShmPixmapInfo describes the segment behind a surface created with
+ * PixmapOptions.Shm. The segment is already marked for removal, which Linux
+ * still lets other processes attach to, and disappears once every process
+ * has detached after the surface is destroyed.
+ */
+func (surface *Surface) ShmPixmapInfo() (ShmPixmapInfo, error) {
+	var info ShmPixmapInfo
+	if surface.shmPixmap == nil {
+		return info, errors.New("surface is not a shared memory pixmap")
+	}
+
+	xDisplay := surface.Display.xDisplay
+	layout := C.XShmCreateImage(xDisplay, surface.visual, C.uint(surface.depth), C.ZPixmap, nil, nil, C.uint(surface.width), C.uint(surface.height))
+	if layout == nil {
+		return info, errors.New("XShmCreateImage failed")
+	}
+	defer C.destroyShmXImage(layout)
+
+	premultiplied, alphaErr := surface.alphaPremultiplied()
+	if alphaErr != nil {
+		return info, alphaErr
+	}
+
+	info.SegmentID = int(surface.shmPixmap.info.shmid)
+	info.Width = surface.width
+	info.Height = surface.height
+	info.Stride = int(layout.bytes_per_line)
+	info.BitsPerPixel = int(layout.bits_per_pixel)
+	info.Depth = int(layout.depth)
+	info.MSBFirst = layout.byte_order == C.MSBFirst
+	info.RedMask = uint32(layout.red_mask)
+	info.GreenMask = uint32(layout.green_mask)
+	info.BlueMask = uint32(layout.blue_mask)
+	info.Premultiplied = premultiplied
+	return info, nil
+}
+
+/*
+ * ShmFrameReader reads frames from another process's shared memory pixmap
+ * without an X or EGL connection. The segment is mapped read-only. Nothing
+ * orders the reads with the renderer's writes, so the renderer should call
+ * WaitClient after each frame and tell the reader it is ready through a
+ * pipe or similar.
+ */
+type ShmFrameReader struct {
+	Info ShmPixmapInfo
+	address unsafe.Pointer
+	pix []byte
+	format pixelFormat
+}
+
+func OpenShmFrameReader(info ShmPixmapInfo) (*ShmFrameReader, error) {
+	if info.Width <= 0 || info.Height <= 0 || info.Stride <= 0 {
+		return nil, errors.New("shared memory pixmap has no size")
+	}
+	format, formatErr := newPixelFormat(info.BitsPerPixel, info.Depth, info.MSBFirst, info.RedMask, info.GreenMask, info.BlueMask)
+	if formatErr != nil {
+		return nil, formatErr
+	}
+	if info.Stride < info.Width * format.bytesPerPixel() {
+		return nil, errors.New("shared memory pixmap stride is shorter than a row")
+	}
+
+	address, attachErr := C.attachAddress(C.int(info.SegmentID), 1)
+	if uintptr(address) == ^uintptr(0) {
+		return nil, fmt.Errorf("shmat failed: %v", attachErr)
+	}
+
+	var segmentInfo C.struct_shmid_ds
+	C.shmctl(C.int(info.SegmentID), C.IPC_STAT, &segmentInfo)
+	size := info.Stride * info.Height
+	if int(segmentInfo.shm_segsz) < size {
+		C.shmdt(address)
+		return nil, errors.New("shared memory segment is smaller than the pixmap")
+	}
+
+	reader := new(ShmFrameReader)
+	reader.Info = info
+	reader.address = address
+	reader.pix = unsafe.Slice((*byte)(address), size)
+	reader.format = format
+	return reader, nil
+}
+
+/*
+ * Pix returns the mapped pixels in the pixmap's own format, Info.Stride
+ * bytes per row. It is only valid until Close.
+ */
+func (reader *ShmFrameReader) Pix() []byte {
+	return reader.pix
+}
+
+/*
+ * ReadFrame copies the current pixels into an *image.RGBA if they are
+ * premultiplied or opaque, or an *image.NRGBA otherwise, with 16-bit
+ * variants for visuals deeper than 8 bits per component.
+ */
+func (reader *ShmFrameReader) ReadFrame() (image.Image, error) {
+	if reader.pix == nil {
+		return nil, errors.New("frame reader is closed")
+	}
+
+	info := reader.Info
+	format := reader.format
+	bounds := image.Rect(0, 0, info.Width, info.Height)
+	premultiplied := info.Premultiplied || !format.hasAlpha()
+
+	var pix []byte
+	var stride int
+	var goImage image.Image
+	decode := format.decodeRow
+	switch {
+		case format.isDeep() && premultiplied:
+			deep := image.NewRGBA64(bounds)
+			goImage, pix, stride = deep, deep.Pix, deep.Stride
+			decode = format.decodeRow64
+		case format.isDeep():
+			deep := image.NewNRGBA64(bounds)
+			goImage, pix, stride = deep, deep.Pix, deep.Stride
+			decode = format.decodeRow64
+		case premultiplied:
+			rgba := image.NewRGBA(bounds)
+			goImage, pix, stride = rgba, rgba.Pix, rgba.Stride
+		default:
+			nrgba := image.NewNRGBA(bounds)
+			goImage, pix, stride = nrgba, nrgba.Pix, nrgba.Stride
+	}
+
+	for y := 0; y < info.Height; y++ {
+		decode(pix[y * stride:], reader.pix[y * info.Stride:], info.Width)
+	}
+	return goImage, nil
+}
+
+func (reader *ShmFrameReader) Close() error {
+	if reader.pix == nil {
+		return nil
+	}
+	reader.pix = nil
+	if C.shmdt(reader.address) != 0 {
+		return errors.New("shmdt failed")
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package egl
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestShmReadback(t *testing.T) {
+	display := openTestXDisplay(t)
+	defer display.Close()
+	if !display.ShmAvailable() {
+		t.Skip("MIT-SHM is not available on this display")
+	}
+	config := chooseTestConfig(t, display, PixmapBit)
+	surface, surfaceErr := display.CreatePixmapSurface(config, nil, 32, 16)
+	if surfaceErr != nil {
+		t.Skip(surfaceErr)
+	}
+	defer surface.Destroy()
+
+	src := image.NewRGBA(image.Rect(0, 0, 32, 16))
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 32; x++ {
+			src.SetRGBA(x, y, color.RGBA{uint8(x * 8), uint8(y * 16), 0x80, 0xFF})
+		}
+	}
+	writeErr := surface.WriteImage(src, image.Point{})
+	if writeErr != nil {
+		t.Fatal(writeErr)
+	}
+	want, wantErr := surface.CopyBuffers()
+	if wantErr != nil {
+		t.Fatal(wantErr)
+	}
+
+	shmErr := surface.EnableShm()
+	if shmErr != nil {
+		t.Fatal(shmErr)
+	}
+	got, gotErr := surface.CopyBuffers()
+	if gotErr != nil {
+		t.Fatal(gotErr)
+	}
+	if surface.shm.xImage == nil {
+		t.Error("CopyBuffers didn't read through shared memory")
+	}
+	compareTestImages(t, "CopyBuffers", got, want)
+
+	view, viewErr := surface.ShmImageView()
+	if viewErr != nil {
+		t.Fatal(viewErr)
+	}
+	compareTestImages(t, "ShmImageView", view, want)
+	_, heldErr := surface.ShmImageView()
+	if heldErr == nil {
+		t.Error("ShmImageView returned a second view before the first was released")
+	}
+	view.Release()
+}
+
+func compareTestImages(t *testing.T, name string, got, want image.Image) {
+	if got.Bounds() != want.Bounds() {
+		t.Fatalf("%s bounds are %v, want %v", name, got.Bounds(), want.Bounds())
+	}
+	bounds := want.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			gotR, gotG, gotB, gotA := got.At(x, y).RGBA()
+			wantR, wantG, wantB, wantA := want.At(x, y).RGBA()
+			if gotR != wantR || gotG != wantG || gotB != wantB || gotA != wantA {
+				t.Fatalf("%s pixel %d,%d is %v, want %v", name, x, y, got.At(x, y), want.At(x, y))
+			}
+		}
+	}
+}
@@ -9,6 +9,12 @@ const EGLSurface kNoSurface = EGL_NO_SURFACE;
 */
 import "C"
 
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
 var noSurface C.EGLSurface = C.kNoSurface
 
 func destroySurface(surface *Surface) {
@@ -26,6 +32,10 @@ func (surface *Surface) Query(name Attrib) (Attrib, error) {
 }
 
 func (surface *Surface) SwapBuffers() error {
+	if surface.timer != nil {
+		defer surface.timer.record(time.Now(), surface.swapInterval)
+	}
+
 	success := C.eglSwapBuffers(surface.Display.eglDisplay, surface.eglSurface)
 	if success == C.EGL_FALSE {
 		return getError()
@@ -33,3 +43,160 @@ func (surface *Surface) SwapBuffers() error {
 	return nil
 }
 
+
+func (surface *Surface) Config() Config {
+	return surface.config
+}
+
+func (surface *Surface) isCurrentDraw() bool {
+	return C.eglGetCurrentSurface(Draw) == surface.eglSurface
+}
+
+/*
+ * SetAttrib sets a surface attribute with eglSurfaceAttrib. Prefer the typed
+ * setters below, which check the value against the surface's config first.
+ */
+func (surface *Surface) SetAttrib(name, value Attrib) error {
+	success := C.eglSurfaceAttrib(surface.Display.eglDisplay, surface.eglSurface, C.EGLint(name), C.EGLint(value))
+	if success == C.EGL_FALSE {
+		return getError()
+	}
+
+	if surface.attribs == nil {
+		surface.attribs = make(map[Attrib]Attrib)
+	}
+	surface.attribs[name] = value
+	return nil
+}
+
+func (surface *Surface) configSurfaceType() (Attrib, error) {
+	return surface.Display.GetConfigAttrib(surface.config, SurfaceType)
+}
+
+/*
+ * SetSwapBehavior chooses whether the color buffer is preserved
+ * (BufferPreserved) or may be destroyed (BufferDestroyed) by SwapBuffers.
+ * Preserving requires a config with SwapBehaviorPreservedBit.
+ */
+func (surface *Surface) SetSwapBehavior(behavior Attrib) error {
+	switch behavior {
+		case BufferDestroyed:
+		case BufferPreserved:
+			surfaceType, typeErr := surface.configSurfaceType()
+			if typeErr != nil {
+				return typeErr
+			}
+			if surfaceType & SwapBehaviorPreservedBit == 0 {
+				return errors.New("config does not support preserved swap behavior (SwapBehaviorPreservedBit is not set)")
+			}
+		default:
+			return fmt.Errorf("unknown swap behavior 0x%X, expected BufferPreserved or BufferDestroyed", int(behavior))
+	}
+	return surface.SetAttrib(SwapBehavior, behavior)
+}
+
+/*
+ * SetMultisampleResolve chooses how multisample buffers are resolved.
+ * MultisampleResolveBox requires a config with MultisampleResolveBoxBit.
+ */
+func (surface *Surface) SetMultisampleResolve(resolve Attrib) error {
+	switch resolve {
+		case MultisampleResolveDefault:
+		case MultisampleResolveBox:
+			surfaceType, typeErr := surface.configSurfaceType()
+			if typeErr != nil {
+				return typeErr
+			}
+			if surfaceType & MultisampleResolveBoxBit == 0 {
+				return errors.New("config does not support box filtered multisample resolve (MultisampleResolveBoxBit is not set)")
+			}
+		default:
+			return fmt.Errorf("unknown multisample resolve 0x%X, expected MultisampleResolveDefault or MultisampleResolveBox", int(resolve))
+	}
+	return surface.SetAttrib(MultisampleResolve, resolve)
+}
+
+/*
+ * SetMipmapLevel chooses the mipmap level rendered to. Levels other than 0
+ * need a texture pbuffer created with MipmapTexture set, and must be less
+ * than MipmapLevels.
+ */
+func (surface *Surface) SetMipmapLevel(level int) error {
+	if level < 0 {
+		return fmt.Errorf("mipmap level %d is negative", level)
+	}
+	if level > 0 {
+		mipmapped, queryErr := surface.Query(MipmapTexture)
+		if queryErr != nil {
+			return queryErr
+		}
+		if mipmapped == C.EGL_FALSE {
+			return fmt.Errorf("cannot render to mipmap level %d, surface was not created with MipmapTexture", level)
+		}
+		levels, levelsErr := surface.MipmapLevels()
+		if levelsErr != nil {
+			return levelsErr
+		}
+		if level >= levels {
+			return fmt.Errorf("cannot render to mipmap level %d, surface only has %d levels", level, levels)
+		}
+	}
+	return surface.SetAttrib(MipmapLevel, Attrib(level))
+}
+
+/*
+ * SetSwapInterval sets the minimum number of video frames between buffer
+ * swaps. The interval is clamped to the config's MinSwapInterval and
+ * MaxSwapInterval, and the interval actually used is returned. The surface
+ * must be the current draw surface on the calling thread, since that is the
+ * surface eglSwapInterval applies to.
+ */
+func (surface *Surface) SetSwapInterval(interval int) (int, error) {
+	if !surface.isCurrentDraw() {
+		return 0, errors.New("cannot set swap interval, surface is not the current draw surface on this thread")
+	}
+
+	display := surface.Display
+	minInterval, minErr := display.GetConfigAttrib(surface.config, MinSwapInterval)
+	if minErr != nil {
+		return 0, minErr
+	}
+	maxInterval, maxErr := display.GetConfigAttrib(surface.config, MaxSwapInterval)
+	if maxErr != nil {
+		return 0, maxErr
+	}
+	if interval < int(minInterval) {
+		interval = int(minInterval)
+	}
+	if interval > int(maxInterval) {
+		interval = int(maxInterval)
+	}
+
+	success := C.eglSwapInterval(display.eglDisplay, C.EGLint(interval))
+	if success == C.EGL_FALSE {
+		return 0, getError()
+	}
+	surface.swapInterval = interval
+	return interval, nil
+}
+
+/*
+ * copySettings applies the attributes and swap interval set on old to
+ * surface, which replaces it. The swap interval is only restored when
+ * surface is current.
+ */
+func (surface *Surface) copySettings(old *Surface) error {
+	for name, value := range(old.attribs) {
+		setErr := surface.SetAttrib(name, value)
+		if setErr != nil {
+			return setErr
+		}
+	}
+	if old.swapInterval != 0 && surface.isCurrentDraw() {
+		_, intervalErr := surface.SetSwapInterval(old.swapInterval)
+		if intervalErr != nil {
+			return intervalErr
+		}
+	}
+	return nil
+}
@@ -0,0 +1,364 @@
+package egl
+
+/*
+#cgo pkg-config: egl
+
+#include <EGL/egl.h>
+#include <EGL/eglext.h>
+
+// Core EGL 1.5 and the KHR extensions differ only in the attribute type,
+// EGLAttrib or EGLint, so the same trampolines serve both.
+typedef EGLSync (*createSyncFunc)(EGLDisplay display, EGLenum type, const void *attribList);
+typedef EGLint (*clientWaitSyncFunc)(EGLDisplay display, EGLSync sync, EGLint flags, EGLTime timeout);
+typedef EGLBoolean (*getSyncAttribFunc)(EGLDisplay display, EGLSync sync, EGLint attribute, EGLAttrib *value);
+typedef EGLBoolean (*getSyncAttribKHRFunc)(EGLDisplay display, EGLSync sync, EGLint attribute, EGLint *value);
+typedef EGLBoolean (*destroySyncFunc)(EGLDisplay display, EGLSync sync);
+typedef EGLBoolean (*signalSyncFunc)(EGLDisplay display, EGLSync sync, EGLenum mode);
+// eglWaitSync returns EGLBoolean and eglWaitSyncKHR EGLint, which are the
+// same size.
+typedef EGLint (*waitSyncFunc)(EGLDisplay display, EGLSync sync, EGLint flags);
+
+static EGLSync callCreateSync(void *function, EGLDisplay display, EGLenum type) {
+	return ((createSyncFunc)function)(display, type, NULL);
+}
+
+static EGLint callClientWaitSync(void *function, EGLDisplay display, EGLSync sync, EGLint flags, EGLTime timeout) {
+	return ((clientWaitSyncFunc)function)(display, sync, flags, timeout);
+}
+
+static EGLBoolean callGetSyncAttrib(void *function, int khr, EGLDisplay display, EGLSync sync, EGLint attribute, EGLint *value) {
+	if (khr) {
+		return ((getSyncAttribKHRFunc)function)(display, sync, attribute, value);
+	}
+	EGLAttrib wide = 0;
+	EGLBoolean success = ((getSyncAttribFunc)function)(display, sync, attribute, &wide);
+	*value = (EGLint)wide;
+	return success;
+}
+
+static EGLBoolean callDestroySync(void *function, EGLDisplay display, EGLSync sync) {
+	return ((destroySyncFunc)function)(display, sync);
+}
+
+static EGLint callWaitSync(void *function, EGLDisplay display, EGLSync sync) {
+	return ((waitSyncFunc)function)(display, sync, 0);
+}
+
+static EGLBoolean callSignalSync(void *function, EGLDisplay display, EGLSync sync, EGLenum mode) {
+	return ((signalSyncFunc)function)(display, sync, mode);
+}
+*/
+import "C"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// ClientWait blocks in eglClientWaitSync for at most this long at a time,
+// so it notices cancellation promptly.
+const syncWaitSlice = 5 * time.Millisecond
+
+type syncFuncs struct {
+	create unsafe.Pointer
+	clientWait unsafe.Pointer
+	getAttrib unsafe.Pointer
+	destroy unsafe.Pointer
+	signal unsafe.Pointer
+	khr bool
+}
+
+/*
+ * Sync is a fence or reusable sync object. Fences signal once the commands
+ * issued before them complete; reusable syncs are signaled and reset with
+ * Signal. Syncs are destroyed when garbage collected, but Destroy releases
+ * them sooner.
+ */
+type Sync struct {
+	Display *Display
+	eglSync C.EGLSync
+	syncType Attrib
+	funcs syncFuncs
+
+	lock sync.RWMutex // held for writing only by Destroy
+	flushOnce sync.Once
+}
+
+/*
+ * resolveSyncFuncs finds the functions for syncType. Fences use core EGL
+ * 1.5 when available and EGL_KHR_fence_sync otherwise. Reusable syncs only
+ * exist in EGL_KHR_reusable_sync.
+ */
+func (display *Display) resolveSyncFuncs(syncType Attrib) (syncFuncs, error) {
+	var funcs syncFuncs
+	switch syncType {
+		case SyncFence:
+			major, minor := display.GetVersion()
+			if major > 1 || major == 1 && minor >= 5 {
+				funcs.create = procAddress("eglCreateSync")
+				funcs.clientWait = procAddress("eglClientWaitSync")
+				funcs.getAttrib = procAddress("eglGetSyncAttrib")
+				funcs.destroy = procAddress("eglDestroySync")
+				break
+			}
+			if !display.HasExtension("EGL_KHR_fence_sync") {
+				return funcs, errors.New("fence syncs require EGL 1.5 or EGL_KHR_fence_sync")
+			}
+			funcs.khr = true
+		case SyncReusable:
+			if !display.HasExtension("EGL_KHR_reusable_sync") {
+				return funcs, errors.New("reusable syncs require EGL_KHR_reusable_sync")
+			}
+			funcs.khr = true
+			funcs.signal = procAddress("eglSignalSyncKHR")
+			if funcs.signal == nil {
+				return funcs, errors.New("eglSignalSyncKHR could not be resolved")
+			}
+		default:
+			return funcs, fmt.Errorf("unknown sync type 0x%X, expected SyncFence or SyncReusable", int(syncType))
+	}
+
+	if funcs.khr {
+		funcs.create = procAddress("eglCreateSyncKHR")
+		funcs.clientWait = procAddress("eglClientWaitSyncKHR")
+		funcs.getAttrib = procAddress("eglGetSyncAttribKHR")
+		funcs.destroy = procAddress("eglDestroySyncKHR")
+	}
+	if funcs.create == nil || funcs.clientWait == nil || funcs.getAttrib == nil || funcs.destroy == nil {
+		return funcs, errors.New("eglGetProcAddress could not resolve the sync functions")
+	}
+	return funcs, nil
+}
+
+/*
+ * CreateFenceSync inserts a fence into the command stream of the context
+ * current on the calling thread. It signals once every command issued
+ * before it has completed.
+ */
+func (display *Display) CreateFenceSync() (*Sync, error) {
+	return display.createSync(SyncFence)
+}
+
+// CreateReusableSync creates an unsignaled sync that is signaled and reset
+// with Signal.
+func (display *Display) CreateReusableSync() (*Sync, error) {
+	return display.createSync(SyncReusable)
+}
+
+func (display *Display) createSync(syncType Attrib) (*Sync, error) {
+	funcs, funcsErr := display.resolveSyncFuncs(syncType)
+	if funcsErr != nil {
+		return nil, funcsErr
+	}
+
+	eglSync := C.callCreateSync(funcs.create, display.eglDisplay, C.EGLenum(syncType))
+	if eglSync == nil {
+		return nil, getError()
+	}
+
+	s := new(Sync)
+	s.Display = display
+	s.eglSync = eglSync
+	s.syncType = syncType
+	s.funcs = funcs
+	runtime.SetFinalizer(s, (*Sync).Destroy)
+	return s, nil
+}
+
+func (s *Sync) Type() Attrib {
+	return s.syncType
+}
+
+func (s *Sync) getAttrib(name Attrib) (Attrib, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.eglSync == nil {
+		return None, errors.New("sync has been destroyed")
+	}
+
+	var value C.EGLint
+	success := C.callGetSyncAttrib(s.funcs.getAttrib, boolInt(s.funcs.khr), s.Display.eglDisplay, s.eglSync, C.EGLint(name), &value)
+	if success == C.EGL_FALSE {
+		return None, getError()
+	}
+	return Attrib(value), nil
+}
+
+// Signaled reports whether the sync is currently signaled, without waiting.
+func (s *Sync) Signaled() (bool, error) {
+	status, statusErr := s.getAttrib(SyncStatus)
+	if statusErr != nil {
+		return false, statusErr
+	}
+	return status == Signaled, nil
+}
+
+// Condition returns what signals a fence, SyncPriorCommandsComplete.
+func (s *Sync) Condition() (Attrib, error) {
+	if s.syncType != SyncFence {
+		return None, errors.New("only fence syncs have a condition")
+	}
+	return s.getAttrib(SyncCondition)
+}
+
+/*
+ * ClientWait blocks until the sync is signaled, ctx is done, or ctx's
+ * deadline passes, in which case ctx.Err() is returned. The first wait
+ * flushes the current context, so a fence created on this thread can't
+ * wait forever on commands that were never submitted.
+ */
+func (s *Sync) ClientWait(ctx context.Context) error {
+	for {
+		slice := syncWaitSlice
+		deadline, hasDeadline := ctx.Deadline()
+		if hasDeadline {
+			remaining := time.Until(deadline)
+			if remaining < slice {
+				slice = remaining
+			}
+			if slice < 0 {
+				slice = 0
+			}
+		}
+
+		satisfied, waitErr := s.waitSlice(slice)
+		if waitErr != nil {
+			return waitErr
+		}
+		if satisfied {
+			return nil
+		}
+
+		select {
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
+		}
+	}
+}
+
+func (s *Sync) waitSlice(timeout time.Duration) (bool, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.eglSync == nil {
+		return false, errors.New("sync has been destroyed")
+	}
+
+	var flags C.EGLint
+	s.flushOnce.Do(func() {
+		flags = C.EGL_SYNC_FLUSH_COMMANDS_BIT
+	})
+	result := C.callClientWaitSync(s.funcs.clientWait, s.Display.eglDisplay, s.eglSync, flags, C.EGLTime(timeout.Nanoseconds()))
+	switch result {
+		case C.EGL_CONDITION_SATISFIED:
+			return true, nil
+		case C.EGL_TIMEOUT_EXPIRED:
+			return false, nil
+	}
+	errorCode := C.eglGetError()
+	if errorCode == C.EGL_SUCCESS {
+		// Mesa returns EGL_FALSE without an error when a reusable sync
+		// is signaled during the wait
+		var status C.EGLint
+		success := C.callGetSyncAttrib(s.funcs.getAttrib, boolInt(s.funcs.khr), s.Display.eglDisplay, s.eglSync, SyncStatus, &status)
+		if success != C.EGL_FALSE && status == Signaled {
+			return true, nil
+		}
+	}
+	return false, errorForCode(errorCode)
+}
+
+/*
+ * Signal sets a reusable sync's status to Signaled, waking any waiters, or
+ * resets it to Unsignaled.
+ */
+func (s *Sync) Signal(mode Attrib) error {
+	if s.syncType != SyncReusable {
+		return errors.New("only reusable syncs can be signaled")
+	}
+	if mode != Signaled && mode != Unsignaled {
+		return fmt.Errorf("unknown sync mode 0x%X, expected Signaled or Unsignaled", int(mode))
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.eglSync == nil {
+		return errors.New("sync has been destroyed")
+	}
+	success := C.callSignalSync(s.funcs.signal, s.Display.eglDisplay, s.eglSync, C.EGLenum(mode))
+	if success == C.EGL_FALSE {
+		return getError()
+	}
+	return nil
+}
+
+/*
+ * WaitSync makes the GPU wait for s before running commands issued to the
+ * context afterward, without blocking the calling thread, which must have
+ * the context current. The fence must have been flushed by the context
+ * that created it, as Publish and ClientWait do.
+ */
+func (context *Context) WaitSync(s *Sync) error {
+	display := context.Display
+	if s.Display != display {
+		return errors.New("sync and context belong to different displays")
+	}
+	if C.eglGetCurrentContext() != context.eglContext {
+		return errors.New("cannot wait for sync, context is not current on this thread")
+	}
+
+	name := "eglWaitSync"
+	if s.funcs.khr {
+		if !display.HasExtension("EGL_KHR_wait_sync") {
+			return errors.New("server waits require EGL 1.5 or EGL_KHR_wait_sync")
+		}
+		name = "eglWaitSyncKHR"
+	}
+	function := procAddress(name)
+	if function == nil {
+		return fmt.Errorf("%s could not be resolved", name)
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.eglSync == nil {
+		return errors.New("sync has been destroyed")
+	}
+	success := C.callWaitSync(function, display.eglDisplay, s.eglSync)
+	if success == C.EGL_FALSE {
+		return getError()
+	}
+	return nil
+}
+
+/*
+ * Destroy frees the sync. It may be called more than once, and is called
+ * by the finalizer if it never was.
+ */
+func (s *Sync) Destroy() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.eglSync == nil {
+		return nil
+	}
+
+	success := C.callDestroySync(s.funcs.destroy, s.Display.eglDisplay, s.eglSync)
+	s.eglSync = nil
+	runtime.SetFinalizer(s, nil)
+	if success == C.EGL_FALSE {
+		return getError()
+	}
+	return nil
+}
+
+func boolInt(value bool) C.int {
+	if value {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,132 @@
+package egl
+
+/*
+#cgo pkg-config: egl
+
+#include <EGL/egl.h>
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+/*
+ * CreateTexturePbufferSurface creates a width by height pbuffer that can be
+ * bound as a GL texture with BindTexImage. format is TextureRGB or
+ * TextureRGBA and must be allowed by the config's BindToTextureRGB or
+ * BindToTextureRGBA. If mipmapped is true, space is allocated for every
+ * mipmap level, each of which can be rendered to after SetMipmapLevel.
+ * attribList may hold other pbuffer attributes, such as GLColorspace.
+ */
+func (display *Display) CreateTexturePbufferSurface(config Config, format Attrib, width, height int, mipmapped bool, attribList []Attrib) (*Surface, error) {
+	if width <= 0 || height <= 0 {
+		return nil, errors.New("surface width and height must be positive")
+	}
+
+	var bindAttrib Attrib
+	switch format {
+		case TextureRGB:
+			bindAttrib = BindToTextureRGB
+		case TextureRGBA:
+			bindAttrib = BindToTextureRGBA
+		default:
+			return nil, fmt.Errorf("unknown texture format 0x%X, expected TextureRGB or TextureRGBA", int(format))
+	}
+
+	surfaceType, typeErr := display.GetConfigAttrib(config, SurfaceType)
+	if typeErr != nil {
+		return nil, typeErr
+	}
+	if surfaceType & PbufferBit == 0 {
+		return nil, errors.New("config does not support pbuffers (PbufferBit is not set)")
+	}
+	bindable, bindErr := display.GetConfigAttrib(config, bindAttrib)
+	if bindErr != nil {
+		return nil, bindErr
+	}
+	if bindable == C.EGL_FALSE {
+		return nil, fmt.Errorf("config cannot be bound as a texture in this format (%v is not set)", bindAttrib)
+	}
+
+	var mipmapValue Attrib = C.EGL_FALSE
+	if mipmapped {
+		mipmapValue = C.EGL_TRUE
+	}
+	pbufferAttribs := stripSizeAttribs(attribList)
+	pbufferAttribs = append(pbufferAttribs,
+		TextureFormat, format,
+		TextureTarget, Texture2D,
+		MipmapTexture, mipmapValue,
+		Width, Attrib(width),
+		Height, Attrib(height),
+		None)
+	return display.CreatePbufferSurface(config, pbufferAttribs)
+}
+
+/*
+ * BindTexImage makes the surface's color buffer the image of the texture
+ * currently bound to GL_TEXTURE_2D in the calling thread's context, so it
+ * can be sampled without reading pixels back. buffer must be BackBuffer.
+ * Pixmap surfaces can be bound too when they were created with a texture
+ * format under EGL_NOK_texture_from_pixmap.
+ * The surface can't be rendered to until ReleaseTexImage is called.
+ */
+func (surface *Surface) BindTexImage(buffer Attrib) error {
+	if buffer != BackBuffer {
+		return fmt.Errorf("unknown texture buffer 0x%X, expected BackBuffer", int(buffer))
+	}
+	if surface.texImageBound {
+		return errors.New("surface is already bound as a texture")
+	}
+	format, formatErr := surface.Query(TextureFormat)
+	if formatErr != nil {
+		return formatErr
+	}
+	if format == NoTexture {
+		return errors.New("surface was not created with a texture format")
+	}
+
+	success := C.eglBindTexImage(surface.Display.eglDisplay, surface.eglSurface, C.EGLint(buffer))
+	if success == C.EGL_FALSE {
+		return getError()
+	}
+	surface.texImageBound = true
+	return nil
+}
+
+/*
+ * ReleaseTexImage detaches the surface from the texture it was bound to
+ * with BindTexImage, so it can be rendered to again.
+ */
+func (surface *Surface) ReleaseTexImage(buffer Attrib) error {
+	if buffer != BackBuffer {
+		return fmt.Errorf("unknown texture buffer 0x%X, expected BackBuffer", int(buffer))
+	}
+	if !surface.texImageBound {
+		return nil
+	}
+
+	success := C.eglReleaseTexImage(surface.Display.eglDisplay, surface.eglSurface, C.EGLint(buffer))
+	if success == C.EGL_FALSE {
+		return getError()
+	}
+	surface.texImageBound = false
+	return nil
+}
+
+// MipmapLevels returns how many mipmap levels a mipmapped texture pbuffer
+// has, down to 1 by 1.
+func (surface *Surface) MipmapLevels() (int, error) {
+	bounds, boundsErr := surface.bounds()
+	if boundsErr != nil {
+		return 0, boundsErr
+	}
+	size := bounds.Dx()
+	if bounds.Dy() > size {
+		size = bounds.Dy()
+	}
+	return bits.Len(uint(size)), nil
+}
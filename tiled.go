@@ -0,0 +1,318 @@
+package egl
+
+import (
+	"errors"
+	"image"
+	"image/draw"
+	"io"
+)
+
+/*
+ * Tile is one piece of a tiled render. Rect is the part of the full image
+ * it covers, with Go's top-left origin, and Width and Height are the full
+ * image's size. Viewport is where the tile must be drawn in the pbuffer, in
+ * GL window coordinates, so it can be passed straight to glViewport.
+ */
+type Tile struct {
+	Rect image.Rectangle
+	Width, Height int
+	Viewport image.Rectangle
+}
+
+/*
+ * Projection returns the scale and offset that map the full image's clip
+ * space onto this tile: x' = x * scaleX + offsetX * w, and likewise for y.
+ * Applying them after the usual projection matrix draws the tile's part of
+ * the scene.
+ */
+func (tile Tile) Projection() (scaleX, scaleY, offsetX, offsetY float32) {
+	tileWidth := float32(tile.Rect.Dx())
+	tileHeight := float32(tile.Rect.Dy())
+	// GL counts rows from the bottom
+	left := float32(tile.Rect.Min.X)
+	bottom := float32(tile.Height - tile.Rect.Max.Y)
+
+	scaleX = float32(tile.Width) / tileWidth
+	scaleY = float32(tile.Height) / tileHeight
+	offsetX = (float32(tile.Width) - 2 * left - tileWidth) / tileWidth
+	offsetY = (float32(tile.Height) - 2 * bottom - tileHeight) / tileHeight
+	return scaleX, scaleY, offsetX, offsetY
+}
+
+// TileDrawFunc draws one tile into the current pbuffer.
+type TileDrawFunc func(tile Tile) error
+
+/*
+ * TiledRenderer renders images larger than a pbuffer can be by drawing them
+ * a tile at a time into one pbuffer and reading each tile back with
+ * ReadPixels. The context is made current on the calling thread, which
+ * should be locked with runtime.LockOSThread.
+ */
+type TiledRenderer struct {
+	Display *Display
+	context *Context
+	surface *Surface
+	tileWidth, tileHeight int
+	tileImage draw.Image
+}
+
+/*
+ * CreateTiledRenderer creates a tileWidth by tileHeight pbuffer for context
+ * to draw tiles into. Tile sizes of 0 mean as large as the config allows, and
+ * larger sizes are clamped to MaxPbufferWidth, MaxPbufferHeight and
+ * MaxPbufferPixels. attribList may hold other pbuffer attributes, such as
+ * GLColorspace, but not Width or Height.
+ */
+func (display *Display) CreateTiledRenderer(config Config, context *Context, attribList []Attrib, tileWidth, tileHeight int) (*TiledRenderer, error) {
+	if context == nil {
+		return nil, errors.New("tiled rendering requires a context")
+	}
+	if tileWidth < 0 || tileHeight < 0 {
+		return nil, errors.New("tile width and height must not be negative")
+	}
+
+	if tileWidth == 0 || tileHeight == 0 {
+		maxWidth, widthErr := display.GetConfigAttrib(config, MaxPbufferWidth)
+		if widthErr != nil {
+			return nil, widthErr
+		}
+		maxHeight, heightErr := display.GetConfigAttrib(config, MaxPbufferHeight)
+		if heightErr != nil {
+			return nil, heightErr
+		}
+		if tileWidth == 0 {
+			tileWidth = int(maxWidth)
+		}
+		if tileHeight == 0 {
+			tileHeight = int(maxHeight)
+		}
+		if tileWidth <= 0 || tileHeight <= 0 {
+			return nil, errors.New("config reports no maximum pbuffer size, tile size must be given")
+		}
+	}
+	tileWidth, tileHeight, clampErr := display.clampPbufferSize(config, tileWidth, tileHeight)
+	if clampErr != nil {
+		return nil, clampErr
+	}
+
+	pbufferAttribs := stripSizeAttribs(attribList)
+	pbufferAttribs = append(pbufferAttribs, Width, Attrib(tileWidth), Height, Attrib(tileHeight), None)
+	surface, surfaceErr := display.CreatePbufferSurface(config, pbufferAttribs)
+	if surfaceErr != nil {
+		return nil, surfaceErr
+	}
+
+	renderer := new(TiledRenderer)
+	renderer.Display = display
+	renderer.context = context
+	renderer.surface = surface
+	renderer.tileWidth = tileWidth
+	renderer.tileHeight = tileHeight
+	return renderer, nil
+}
+
+// TileSize returns the size of the pbuffer tiles are drawn into.
+func (renderer *TiledRenderer) TileSize() (width, height int) {
+	return renderer.tileWidth, renderer.tileHeight
+}
+
+func (renderer *TiledRenderer) Surface() *Surface {
+	return renderer.surface
+}
+
+/*
+ * Render draws a width by height image tile by tile and stitches the tiles
+ * into one image, whose type follows the config as with ReadPixels.
+ */
+func (renderer *TiledRenderer) Render(width, height int, drawTile TileDrawFunc) (image.Image, error) {
+	if width <= 0 || height <= 0 {
+		return nil, errors.New("image width and height must be positive")
+	}
+	kind, premultiplied, formatErr := renderer.readbackFormat()
+	if formatErr != nil {
+		return nil, formatErr
+	}
+
+	goImage := newReadbackImage(kind, image.Rect(0, 0, width, height), premultiplied)
+	for top := 0; top < height; top += renderer.tileHeight {
+		for _, tile := range(renderer.band(width, height, top)) {
+			renderErr := renderer.renderTile(tile, drawTile, goImage, tile.Rect.Min)
+			if renderErr != nil {
+				return nil, renderErr
+			}
+		}
+	}
+	return goImage, nil
+}
+
+/*
+ * RenderPNG draws a width by height image tile by tile and streams it to w
+ * as a PNG, tagged like CopyBuffersTagged. Only one row of tiles is
+ * held in memory at a time. Configs with more than 8 bits per component
+ * produce 16-bit PNGs.
+ */
+func (renderer *TiledRenderer) RenderPNG(w io.Writer, width, height int, drawTile TileDrawFunc) error {
+	if width <= 0 || height <= 0 {
+		return errors.New("image width and height must be positive")
+	}
+	kind, premultiplied, formatErr := renderer.readbackFormat()
+	if formatErr != nil {
+		return formatErr
+	}
+	colorspace, colorspaceErr := renderer.surface.pixelColorspace()
+	if colorspaceErr != nil {
+		return colorspaceErr
+	}
+
+	encoder, encoderErr := newPNGStreamEncoder(w, width, height, kind != readback8, colorspace)
+	if encoderErr != nil {
+		return encoderErr
+	}
+
+	band := newReadbackImage(kind, image.Rect(0, 0, width, renderer.tileHeight), premultiplied)
+	for top := 0; top < height; top += renderer.tileHeight {
+		bandHeight := 0
+		for _, tile := range(renderer.band(width, height, top)) {
+			renderErr := renderer.renderTile(tile, drawTile, band, image.Pt(tile.Rect.Min.X, 0))
+			if renderErr != nil {
+				return renderErr
+			}
+			bandHeight = tile.Rect.Dy()
+		}
+		for y := 0; y < bandHeight; y++ {
+			rowErr := encoder.writeRow(band, y)
+			if rowErr != nil {
+				return rowErr
+			}
+		}
+	}
+	return encoder.close()
+}
+
+func (renderer *TiledRenderer) readbackFormat() (int, bool, error) {
+	premultiplied, alphaErr := renderer.surface.alphaPremultiplied()
+	if alphaErr != nil {
+		return 0, false, alphaErr
+	}
+	kind, kindErr := renderer.surface.readbackFormat()
+	if kindErr != nil {
+		return 0, false, kindErr
+	}
+	return kind, premultiplied, nil
+}
+
+// band returns the tiles in the row of tiles starting top rows down.
+func (renderer *TiledRenderer) band(width, height, top int) []Tile {
+	bottom := top + renderer.tileHeight
+	if bottom > height {
+		bottom = height
+	}
+
+	var tiles []Tile
+	for left := 0; left < width; left += renderer.tileWidth {
+		right := left + renderer.tileWidth
+		if right > width {
+			right = width
+		}
+		tile := Tile{
+			Rect: image.Rect(left, top, right, bottom),
+			Width: width,
+			Height: height,
+			Viewport: image.Rect(0, 0, right - left, bottom - top),
+		}
+		tiles = append(tiles, tile)
+	}
+	return tiles
+}
+
+/*
+ * renderTile has drawTile draw tile into the pbuffer's bottom-left corner,
+ * then copies it into dst with its top-left corner at at.
+ */
+func (renderer *TiledRenderer) renderTile(tile Tile, drawTile TileDrawFunc, dst draw.Image, at image.Point) error {
+	surface := renderer.surface
+	makeErr := renderer.context.MakeCurrent(surface, surface)
+	if makeErr != nil {
+		return makeErr
+	}
+	drawErr := drawTile(tile)
+	if drawErr != nil {
+		return drawErr
+	}
+
+	if renderer.tileImage == nil {
+		kind, premultiplied, formatErr := renderer.readbackFormat()
+		if formatErr != nil {
+			return formatErr
+		}
+		tileBounds := image.Rect(0, 0, renderer.tileWidth, renderer.tileHeight)
+		renderer.tileImage = newReadbackImage(kind, tileBounds, premultiplied)
+	}
+
+	// the viewport's bottom-left origin puts short tiles at the bottom
+	size := tile.Rect.Size()
+	readRect := image.Rect(0, renderer.tileHeight - size.Y, size.X, renderer.tileHeight)
+	readErr := surface.readPixelsInto(renderer.tileImage, readRect)
+	if readErr != nil {
+		return readErr
+	}
+
+	copyRect(dst, image.Rectangle{at, at.Add(size)}, renderer.tileImage, readRect.Min)
+	return nil
+}
+
+func (renderer *TiledRenderer) Destroy() error {
+	if renderer.surface == nil {
+		return nil
+	}
+	result := renderer.surface.Destroy()
+	renderer.surface = nil
+	renderer.tileImage = nil
+	return result
+}
+
+/*
+ * copyRect copies r of dst from src starting at sp, copying rows of pixel
+ * memory directly when both images are the same type.
+ */
+func copyRect(dst draw.Image, r image.Rectangle, src image.Image, sp image.Point) {
+	switch source := src.(type) {
+		case *image.RGBA:
+			if d, ok := dst.(*image.RGBA); ok {
+				copyPixRows(d.Pix[d.PixOffset(r.Min.X, r.Min.Y):], d.Stride, source.Pix[source.PixOffset(sp.X, sp.Y):], source.Stride, r.Dx() * 4, r.Dy())
+				return
+			}
+		case *image.NRGBA:
+			if d, ok := dst.(*image.NRGBA); ok {
+				copyPixRows(d.Pix[d.PixOffset(r.Min.X, r.Min.Y):], d.Stride, source.Pix[source.PixOffset(sp.X, sp.Y):], source.Stride, r.Dx() * 4, r.Dy())
+				return
+			}
+		case *image.RGBA64:
+			if d, ok := dst.(*image.RGBA64); ok {
+				copyPixRows(d.Pix[d.PixOffset(r.Min.X, r.Min.Y):], d.Stride, source.Pix[source.PixOffset(sp.X, sp.Y):], source.Stride, r.Dx() * 8, r.Dy())
+				return
+			}
+		case *image.NRGBA64:
+			if d, ok := dst.(*image.NRGBA64); ok {
+				copyPixRows(d.Pix[d.PixOffset(r.Min.X, r.Min.Y):], d.Stride, source.Pix[source.PixOffset(sp.X, sp.Y):], source.Stride, r.Dx() * 8, r.Dy())
+				return
+			}
+		case *FloatRGBA:
+			if d, ok := dst.(*FloatRGBA); ok && d.Premultiplied == source.Premultiplied {
+				rowLength := r.Dx() * 4
+				for y := 0; y < r.Dy(); y++ {
+					dstOffset := d.PixOffset(r.Min.X, r.Min.Y + y)
+					srcOffset := source.PixOffset(sp.X, sp.Y + y)
+					copy(d.Pix[dstOffset:dstOffset + rowLength], source.Pix[srcOffset:srcOffset + rowLength])
+				}
+				return
+			}
+	}
+	draw.Draw(dst, r, src, sp, draw.Src)
+}
+
+func copyPixRows(dst []byte, dstStride int, src []byte, srcStride int, rowBytes, rows int) {
+	for y := 0; y < rows; y++ {
+		copy(dst[y * dstStride:y * dstStride + rowBytes], src[y * srcStride:y * srcStride + rowBytes])
+	}
+}
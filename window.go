@@ -0,0 +1,367 @@
+package egl
+
+/*
+#cgo pkg-config: egl x11
+
+#include <EGL/egl.h>
+#include <X11/Xlib.h>
+#include <X11/Xutil.h>
+#include <stdlib.h>
+
+// a macro, since Go can't link against a static const
+#define kWindowEventMask ( \
+	ExposureMask | StructureNotifyMask | \
+	KeyPressMask | KeyReleaseMask | \
+	ButtonPressMask | ButtonReleaseMask | PointerMotionMask)
+
+// windowEvent is a flattened copy of the XEvent fields we care about, since
+// cgo can't look inside the XEvent union.
+typedef struct {
+	int type;
+	int x, y;
+	int width, height;
+	unsigned int state;
+	unsigned int code;
+	unsigned long keysym;
+	int deleteRequest;
+} windowEvent;
+
+static int nextWindowEvent(Display *display, Window window, Atom deleteAtom, windowEvent *out) {
+	XEvent event;
+	if (!XCheckWindowEvent(display, window, kWindowEventMask, &event) &&
+		!XCheckTypedWindowEvent(display, window, ClientMessage, &event)) {
+		return 0;
+	}
+
+	out->type = event.type;
+	switch (event.type) {
+	case Expose:
+		out->x = event.xexpose.x;
+		out->y = event.xexpose.y;
+		out->width = event.xexpose.width;
+		out->height = event.xexpose.height;
+		break;
+	case ConfigureNotify:
+		out->x = event.xconfigure.x;
+		out->y = event.xconfigure.y;
+		out->width = event.xconfigure.width;
+		out->height = event.xconfigure.height;
+		break;
+	case KeyPress:
+	case KeyRelease:
+		out->x = event.xkey.x;
+		out->y = event.xkey.y;
+		out->state = event.xkey.state;
+		out->code = event.xkey.keycode;
+		out->keysym = XLookupKeysym(&event.xkey, 0);
+		break;
+	case ButtonPress:
+	case ButtonRelease:
+		out->x = event.xbutton.x;
+		out->y = event.xbutton.y;
+		out->state = event.xbutton.state;
+		out->code = event.xbutton.button;
+		break;
+	case MotionNotify:
+		out->x = event.xmotion.x;
+		out->y = event.xmotion.y;
+		out->state = event.xmotion.state;
+		break;
+	case ClientMessage:
+		out->deleteRequest = event.xclient.format == 32 &&
+			(Atom)event.xclient.data.l[0] == deleteAtom;
+		break;
+	}
+	return 1;
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// How often the event goroutine polls the X connection when idle.
+const windowPollInterval = 5 * time.Millisecond
+
+type EventType int
+
+const (
+	ExposeEvent EventType = iota
+	ResizeEvent
+	CloseEvent
+	KeyPressEvent
+	KeyReleaseEvent
+	ButtonPressEvent
+	ButtonReleaseEvent
+	PointerMotionEvent
+)
+
+func (eventType EventType) String() string {
+	switch eventType {
+		case ExposeEvent:
+			return "expose"
+		case ResizeEvent:
+			return "resize"
+		case CloseEvent:
+			return "close"
+		case KeyPressEvent:
+			return "key press"
+		case KeyReleaseEvent:
+			return "key release"
+		case ButtonPressEvent:
+			return "button press"
+		case ButtonReleaseEvent:
+			return "button release"
+		case PointerMotionEvent:
+			return "pointer motion"
+	}
+	return "unknown event"
+}
+
+/*
+ * Event describes something that happened to a Window. X and Y are the
+ * exposed area's origin for ExposeEvent and the pointer position for key,
+ * button and motion events. Width and Height are the exposed area for
+ * ExposeEvent and the new window size for ResizeEvent.
+ */
+type Event struct {
+	Type EventType
+	X, Y int
+	Width, Height int
+	Keycode int
+	Keysym int
+	Button int
+	State int // X modifier and button mask
+}
+
+type Window struct {
+	Surface *Surface
+	Events <-chan Event
+
+	xWindow C.Window
+	xColormap C.Colormap
+	deleteAtom C.Atom
+	width, height int
+
+	quit chan struct{}
+	done chan struct{}
+	destroyOnce sync.Once
+}
+
+/*
+ * CreateWindow maps a new top-level X window whose visual matches config's
+ * NativeVisualId and creates a window surface for it. Events for the window
+ * are delivered on the returned Window's Events channel, which is closed by
+ * Destroy. A CloseEvent is sent when the window manager asks the window to
+ * close; the window stays open until Destroy is called.
+ */
+func (display *Display) CreateWindow(config Config, width, height int, title string) (*Window, error) {
+	if display.xDisplay == nil {
+		return nil, errors.New("CreateWindow requires a display opened on an X server")
+	}
+	if width <= 0 || height <= 0 {
+		return nil, errors.New("window width and height must be positive")
+	}
+
+	visualID, visualIDErr := display.GetConfigAttrib(config, NativeVisualId)
+	if visualIDErr != nil {
+		return nil, visualIDErr
+	}
+	if visualID == 0 {
+		return nil, errors.New("config has no native visual, it cannot be used for windows")
+	}
+
+	xDisplay := display.xDisplay
+	screen := C.XDefaultScreen(xDisplay)
+	visual, depth, visualErr := display.findVisual(C.VisualID(visualID), screen)
+	if visualErr != nil {
+		return nil, visualErr
+	}
+
+	rootWindow := C.XRootWindow(xDisplay, screen)
+	colormap := C.XCreateColormap(xDisplay, rootWindow, visual, C.AllocNone)
+
+	var attributes C.XSetWindowAttributes
+	attributes.colormap = colormap
+	attributes.border_pixel = 0
+	attributes.event_mask = C.kWindowEventMask
+	xWindow := C.XCreateWindow(
+		xDisplay,
+		rootWindow,
+		0, 0, C.uint(width), C.uint(height),
+		0,
+		C.int(depth),
+		C.InputOutput,
+		visual,
+		C.CWColormap|C.CWBorderPixel|C.CWEventMask,
+		&attributes)
+	if xWindow == 0 {
+		C.XFreeColormap(xDisplay, colormap)
+		return nil, errors.New("XCreateWindow failed")
+	}
+
+	cTitle := C.CString(title)
+	C.XStoreName(xDisplay, xWindow, cTitle)
+	C.free(unsafe.Pointer(cTitle))
+
+	atomName := C.CString("WM_DELETE_WINDOW")
+	deleteAtom := C.XInternAtom(xDisplay, atomName, C.False)
+	C.free(unsafe.Pointer(atomName))
+	C.XSetWMProtocols(xDisplay, xWindow, &deleteAtom, 1)
+
+	C.XMapWindow(xDisplay, xWindow)
+	C.XFlush(xDisplay)
+
+	eglSurface := C.eglCreateWindowSurface(display.eglDisplay, C.EGLConfig(config), C.EGLNativeWindowType(xWindow), nil)
+	if eglSurface == noSurface {
+		eglErr := getError()
+		C.XDestroyWindow(xDisplay, xWindow)
+		C.XFreeColormap(xDisplay, colormap)
+		return nil, eglErr
+	}
+
+	surface := new(Surface)
+	surface.Display = display
+	surface.eglSurface = eglSurface
+	surface.config = config
+	surface.xWindow = xWindow
+	surface.screen = screen
+	surface.visual = visual
+	surface.depth = depth
+
+	events := make(chan Event, 64)
+	window := new(Window)
+	window.Surface = surface
+	window.Events = events
+	window.xWindow = xWindow
+	window.xColormap = colormap
+	window.deleteAtom = deleteAtom
+	window.width = width
+	window.height = height
+	window.quit = make(chan struct{})
+	window.done = make(chan struct{})
+
+	go window.pumpEvents(events)
+	return window, nil
+}
+
+func (window *Window) pumpEvents(events chan<- Event) {
+	defer close(window.done)
+	defer close(events)
+
+	xDisplay := window.Surface.Display.xDisplay
+	ticker := time.NewTicker(windowPollInterval)
+	defer ticker.Stop()
+
+	for {
+		var xEvent C.windowEvent
+		for C.nextWindowEvent(xDisplay, window.xWindow, window.deleteAtom, &xEvent) != 0 {
+			event, ok := window.translateEvent(&xEvent)
+			if ok {
+				select {
+					case events <- event:
+					case <-window.quit:
+						return
+				}
+			}
+			xEvent = C.windowEvent{}
+		}
+
+		select {
+			case <-ticker.C:
+			case <-window.quit:
+				return
+		}
+	}
+}
+
+func (window *Window) translateEvent(xEvent *C.windowEvent) (Event, bool) {
+	event := Event{
+		X: int(xEvent.x),
+		Y: int(xEvent.y),
+		State: int(xEvent.state),
+	}
+
+	switch xEvent._type {
+		case C.Expose:
+			event.Type = ExposeEvent
+			event.Width = int(xEvent.width)
+			event.Height = int(xEvent.height)
+		case C.ConfigureNotify:
+			// ConfigureNotify also reports moves and restacking
+			width, height := int(xEvent.width), int(xEvent.height)
+			if width == window.width && height == window.height {
+				return event, false
+			}
+			window.width, window.height = width, height
+			event.Type = ResizeEvent
+			event.X, event.Y = 0, 0
+			event.Width = width
+			event.Height = height
+		case C.ClientMessage:
+			if xEvent.deleteRequest == 0 {
+				return event, false
+			}
+			event.Type = CloseEvent
+		case C.KeyPress:
+			event.Type = KeyPressEvent
+			event.Keycode = int(xEvent.code)
+			event.Keysym = int(xEvent.keysym)
+		case C.KeyRelease:
+			event.Type = KeyReleaseEvent
+			event.Keycode = int(xEvent.code)
+			event.Keysym = int(xEvent.keysym)
+		case C.ButtonPress:
+			event.Type = ButtonPressEvent
+			event.Button = int(xEvent.code)
+		case C.ButtonRelease:
+			event.Type = ButtonReleaseEvent
+			event.Button = int(xEvent.code)
+		case C.MotionNotify:
+			event.Type = PointerMotionEvent
+		default:
+			return event, false
+	}
+	return event, true
+}
+
+/*
+ * Resize asks the X server to resize the window. A ResizeEvent follows once
+ * the new size takes effect.
+ */
+func (window *Window) Resize(width, height int) {
+	xDisplay := window.Surface.Display.xDisplay
+	C.XResizeWindow(xDisplay, window.xWindow, C.uint(width), C.uint(height))
+	C.XFlush(xDisplay)
+}
+
+/*
+ * Destroy stops event delivery, closes the Events channel, and destroys the
+ * window surface along with the X window. Only the first call destroys
+ * anything, later and concurrent calls return an error.
+ */
+func (window *Window) Destroy() error {
+	destroyErr := errors.New("window already destroyed")
+	window.destroyOnce.Do(func() {
+		destroyErr = window.destroy()
+	})
+	return destroyErr
+}
+
+func (window *Window) destroy() error {
+	close(window.quit)
+	<-window.done
+
+	result := window.Surface.Destroy()
+
+	xDisplay := window.Surface.Display.xDisplay
+	C.XDestroyWindow(xDisplay, window.xWindow)
+	C.XFreeColormap(xDisplay, window.xColormap)
+	C.XFlush(xDisplay)
+
+	return result
+}
@@ -0,0 +1,97 @@
+package egl
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+/*
+ * openTestXDisplay opens and initializes the display named by $DISPLAY,
+ * such as an Xvfb server, skipping the test when there is none.
+ */
+func openTestXDisplay(tb testing.TB) *Display {
+	if os.Getenv("DISPLAY") == "" {
+		tb.Skip("$DISPLAY is not set, start Xvfb to run X tests")
+	}
+	display, displayErr := OpenMainXDisplay()
+	if displayErr != nil {
+		tb.Skip(displayErr)
+	}
+	initErr := display.Initialize()
+	if initErr != nil {
+		display.Close()
+		tb.Skip(initErr)
+	}
+	return display
+}
+
+func chooseTestConfig(tb testing.TB, display *Display, surfaceType Attrib) Config {
+	configs, configErr := display.ChooseConfig([]Attrib{
+		SurfaceType, surfaceType,
+		RedSize, 8,
+		GreenSize, 8,
+		BlueSize, 8,
+		None,
+	})
+	if configErr != nil || len(configs) == 0 {
+		tb.Skipf("no config for surface type 0x%X: %v", surfaceType, configErr)
+	}
+	return configs[0]
+}
+
+func TestWindowResizeAndDestroy(t *testing.T) {
+	display := openTestXDisplay(t)
+	defer display.Close()
+	config := chooseTestConfig(t, display, WindowBit)
+
+	window, windowErr := display.CreateWindow(config, 64, 48, "egl test")
+	if windowErr != nil {
+		t.Fatal(windowErr)
+	}
+	if window.Surface == nil {
+		t.Fatal("window has no surface")
+	}
+
+	window.Resize(80, 60)
+	timeout := time.After(5 * time.Second)
+	resized := false
+	for !resized {
+		select {
+			case event := <-window.Events:
+				if event.Type == ResizeEvent {
+					if event.Width != 80 || event.Height != 60 {
+						t.Errorf("resized to %dx%d, want 80x60", event.Width, event.Height)
+					}
+					resized = true
+				}
+			case <-timeout:
+				t.Fatal("no ResizeEvent after Resize")
+		}
+	}
+
+	// concurrent calls must destroy the window exactly once
+	var wait sync.WaitGroup
+	results := make([]error, 4)
+	for i := range(results) {
+		wait.Add(1)
+		go func(i int) {
+			defer wait.Done()
+			results[i] = window.Destroy()
+		}(i)
+	}
+	wait.Wait()
+
+	succeeded := 0
+	for _, result := range(results) {
+		if result == nil {
+			succeeded++
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("%d Destroy calls succeeded, want 1", succeeded)
+	}
+	for range(window.Events) {
+	}
+}
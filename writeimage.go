@@ -0,0 +1,178 @@
+package egl
+
+/*
+#cgo pkg-config: egl x11
+
+#include <EGL/egl.h>
+#include <X11/Xlib.h>
+#include <X11/Xutil.h>
+#include <stdlib.h>
+
+static void destroyWriteXImage(XImage *image) {
+	XDestroyImage(image);
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"unsafe"
+)
+
+/*
+ * WriteImage uploads src into a pixmap surface with its top-left corner at
+ * at, converting it to the pixmap's visual format. Pixels falling outside
+ * the surface are dropped. Visuals with more than 8 bits per component
+ * aren't supported. Uses MIT-SHM when EnableShm has been called.
+ * Call WaitNative before rendering over the uploaded pixels with EGL.
+ */
+func (surface *Surface) WriteImage(src image.Image, at image.Point) error {
+	if surface.xPixmap == 0 {
+		return errors.New("WriteImage requires a pixmap surface")
+	}
+
+	bounds, boundsErr := surface.bounds()
+	if boundsErr != nil {
+		return boundsErr
+	}
+	srcBounds := src.Bounds()
+	rect := srcBounds.Sub(srcBounds.Min).Add(at).Intersect(bounds)
+	if rect.Empty() {
+		return nil
+	}
+	srcMin := srcBounds.Min.Add(rect.Min.Sub(at))
+	// checked before the shared memory path, so it isn't disabled for this
+	if surface.visual != nil && visualIsDeep(surface.visual) {
+		return deepWriteErr
+	}
+
+	premultiplied, alphaErr := surface.alphaPremultiplied()
+	if alphaErr != nil {
+		return alphaErr
+	}
+	rows := rgbaRows(src, image.Rectangle{srcMin, srcMin.Add(rect.Size())}, premultiplied)
+
+	if surface.shm != nil && !surface.shm.viewHeld {
+		shmErr := surface.writeShmXImage(rows, rect)
+		if shmErr == nil {
+			return nil
+		}
+		// fall back to XPutImage from now on
+		surface.DisableShm()
+	}
+
+	return surface.putXImage(rows, rect)
+}
+
+var deepWriteErr = errors.New("writing to visuals with more than 8 bits per component is not supported")
+
+func visualIsDeep(visual *C.Visual) bool {
+	for _, mask := range([]C.ulong{visual.red_mask, visual.green_mask, visual.blue_mask}) {
+		if newChannelMask(uint32(mask)).bits > 8 {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+ * rgbaRows returns rect of src as tightly packed 8-bit RGBA, premultiplied
+ * or not, reusing src's pixels when they are already in that form.
+ */
+func rgbaRows(src image.Image, rect image.Rectangle, premultiplied bool) *image.RGBA {
+	if rgba, ok := src.(*image.RGBA); ok && premultiplied {
+		return rgba.SubImage(rect).(*image.RGBA)
+	}
+	if nrgba, ok := src.(*image.NRGBA); ok && !premultiplied {
+		sub := nrgba.SubImage(rect).(*image.NRGBA)
+		return &image.RGBA{Pix: sub.Pix, Stride: sub.Stride, Rect: sub.Rect}
+	}
+
+	if premultiplied {
+		rgba := image.NewRGBA(rect)
+		draw.Draw(rgba, rect, src, rect.Min, draw.Src)
+		return rgba
+	}
+	nrgba := image.NewNRGBA(rect)
+	draw.Draw(nrgba, rect, src, rect.Min, draw.Src)
+	return &image.RGBA{Pix: nrgba.Pix, Stride: nrgba.Stride, Rect: nrgba.Rect}
+}
+
+// encodeXImage packs rows into xImage, whose top-left corner receives the
+// first pixel. xImage must have been created for a visual, so it has masks.
+func encodeXImage(xImage *C.XImage, rows *image.RGBA) error {
+	format, formatErr := xImageFormat(xImage, nil)
+	if formatErr != nil {
+		return formatErr
+	}
+	// rows only have 8 bits per component to give
+	if format.isDeep() {
+		return deepWriteErr
+	}
+
+	width, height := rows.Rect.Dx(), rows.Rect.Dy()
+	bytesPerLine := int(xImage.bytes_per_line)
+	xSlice := unsafe.Slice((*byte)(unsafe.Pointer(xImage.data)), bytesPerLine * int(xImage.height))
+	for y := 0; y < height; y++ {
+		offset := rows.PixOffset(rows.Rect.Min.X, rows.Rect.Min.Y + y)
+		format.encodeRow(xSlice[y * bytesPerLine:], rows.Pix[offset:], width)
+	}
+	return nil
+}
+
+func (surface *Surface) putXImage(rows *image.RGBA, rect image.Rectangle) error {
+	xDisplay := surface.Display.xDisplay
+	visual := surface.visual
+	if visual == nil {
+		// the default visual may be a different depth than the pixmap
+		var info C.XVisualInfo
+		if C.XMatchVisualInfo(xDisplay, surface.screen, C.int(surface.depth), C.TrueColor, &info) == 0 {
+			return fmt.Errorf("no %d-bit TrueColor visual on screen %d for pixmap", surface.depth, surface.screen)
+		}
+		visual = info.visual
+	}
+
+	width, height := C.uint(rect.Dx()), C.uint(rect.Dy())
+	xImage := C.XCreateImage(xDisplay, visual, C.uint(surface.depth), C.ZPixmap, 0, nil, width, height, 32, 0)
+	if xImage == nil {
+		return errors.New("XCreateImage failed")
+	}
+	// XDestroyImage frees the data too
+	xImage.data = (*C.char)(C.malloc(C.size_t(xImage.bytes_per_line) * C.size_t(height)))
+	defer C.destroyWriteXImage(xImage)
+
+	encodeErr := encodeXImage(xImage, rows)
+	if encodeErr != nil {
+		return encodeErr
+	}
+
+	drawable := C.Drawable(surface.xPixmap)
+	gc := C.XCreateGC(xDisplay, drawable, 0, nil)
+	C.XPutImage(xDisplay, drawable, gc, xImage, 0, 0, C.int(rect.Min.X), C.int(rect.Min.Y), width, height)
+	C.XFreeGC(xDisplay, gc)
+	C.XSync(xDisplay, C.False)
+
+	return nil
+}
+
+/*
+ * CreatePixmapSurfaceFromImage creates a pixmap surface the size of src and
+ * uploads src into it.
+ */
+func (display *Display) CreatePixmapSurfaceFromImage(config Config, attribList []Attrib, src image.Image) (*Surface, error) {
+	size := src.Bounds().Size()
+	surface, surfaceErr := display.CreatePixmapSurface(config, attribList, size.X, size.Y)
+	if surfaceErr != nil {
+		return nil, surfaceErr
+	}
+
+	writeErr := surface.WriteImage(src, image.Point{})
+	if writeErr != nil {
+		surface.Destroy()
+		return nil, writeErr
+	}
+	return surface, nil
+}
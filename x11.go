@@ -4,7 +4,14 @@ package egl
 #cgo pkg-config: egl x11
 
 #include <EGL/egl.h>
+#include <X11/Xlib.h>
+#include <X11/Xutil.h>
 #include <stdlib.h>
+
+// XDestroyImage is a macro calling through a function pointer.
+static void destroyXImage(XImage *image) {
+	XDestroyImage(image);
+}
 */
 import "C"
 
@@ -12,33 +19,55 @@ import (
 	"errors"
 	"fmt"
 	"image"
+	"image/color"
+	"image/draw"
 	"os"
 	//"runtime"
 	"strconv"
+	"sync"
+	"time"
 	"unsafe"
 )
 
+// The X error handler is process-wide, so swapping it is serialized.
+var xErrorHandlerLock sync.Mutex
+
 type Display struct {
 	xDisplay *C.Display
 	eglDisplay C.EGLDisplay
 	majorVersion, minorVersion int
+	extensionsOnce sync.Once
+	extensions map[string]bool
+	shmOnce sync.Once
+	shmAvailable bool
+	imagesLock sync.Mutex
+	images map[*Image]bool // live EGLImages, destroyed by Close
 }
 
 type Surface struct {
 	eglSurface C.EGLSurface
 	Display *Display
 	xPixmap C.Pixmap
+	ownsPixmap bool // false for pixmaps wrapped with CreatePixmapSurfaceFromPixmap
+	xWindow C.Window
+	config Config
+	attribs map[Attrib]Attrib // set with SetAttrib
+	swapInterval int
+	timer *FrameTimer
+	width, height int // pixmaps only
+	screen C.int
+	visual *C.Visual // X visual and depth of the pixmap or window
+	depth int
+	readImage *C.XImage // reused by CopyBuffers and CopyBuffersInto
+	rowBuffer []byte
+	shm *shmReadback // set by EnableShm
+	shmPixmap *shmSegment // memory behind an MIT-SHM pixmap
+	texImageBound bool // bound with BindTexImage
+	colorspaceRequested bool // GLColorspace was given at creation
 }
 
-/*
-const pixelByteCount = 2
-const pixelBitCount = pixelByteCount * 8
-const pixelMask = 0x0000FFFF // 32 bits, for 32-bit ARGB
-*/
-
-const pixelByteCount = 4
-const pixelBitCount = pixelByteCount * 8
-const pixelMask = 0xFFFFFFFF // 32 bits, for 32-bit ARGB
+// Plane mask selecting every bit of a pixel for XGetImage.
+const pixelMask = 0xFFFFFFFF
 
 func init() {
 	C.XInitThreads()
@@ -171,6 +200,8 @@ func allXServers() ([]string, error) {
 }
 
 func (display *Display) Close() error {
+	display.destroyImages()
+
 	if display.xDisplay != nil {
 		C.XCloseDisplay(display.xDisplay)
 	}
@@ -185,8 +216,102 @@ func (display *Display) Close() error {
 	return nil
 }
 
+// findVisual looks up the X visual with the given ID on screen, as reported
+// by a config's NativeVisualId attribute.
+func (display *Display) findVisual(visualID C.VisualID, screen C.int) (*C.Visual, int, error) {
+	var template C.XVisualInfo
+	template.visualid = visualID
+	template.screen = screen
+
+	var count C.int
+	info := C.XGetVisualInfo(display.xDisplay, C.VisualIDMask|C.VisualScreenMask, &template, &count)
+	if info == nil || count <= 0 {
+		return nil, 0, fmt.Errorf("no X visual with ID 0x%X on screen %d", visualID, screen)
+	}
+	visual := info.visual
+	depth := int(info.depth)
+	C.XFree(unsafe.Pointer(info))
+
+	return visual, depth, nil
+}
+
+// Selects the display's default screen in PixmapOptions.
+const DefaultScreen = -1
+
+type PixmapOptions struct {
+	Screen int // X screen to create the pixmap on, or DefaultScreen
+	Shm bool // back the pixmap with MIT-SHM memory, see ShmPixmapInfo
+}
+
+/*
+ * pixmapVisual finds the X visual and depth a pixmap for config needs. It
+ * prefers the config's NativeVisualId, and otherwise looks for a TrueColor
+ * visual as deep as the config's BufferSize.
+ */
+func (display *Display) pixmapVisual(config Config, screen C.int) (*C.Visual, int, error) {
+	visualID, visualIDErr := display.GetConfigAttrib(config, NativeVisualId)
+	if visualIDErr != nil {
+		return nil, 0, visualIDErr
+	}
+	if visualID != 0 {
+		visual, depth, visualErr := display.findVisual(C.VisualID(visualID), screen)
+		if visualErr == nil {
+			return visual, depth, nil
+		}
+	}
+
+	bufferSize, bufferSizeErr := display.GetConfigAttrib(config, BufferSize)
+	if bufferSizeErr != nil {
+		return nil, 0, bufferSizeErr
+	}
+	var info C.XVisualInfo
+	status := C.XMatchVisualInfo(display.xDisplay, screen, C.int(bufferSize), C.TrueColor, &info)
+	if status == 0 {
+		return nil, 0, fmt.Errorf("no %d-bit TrueColor visual on screen %d for config", bufferSize, screen)
+	}
+	return info.visual, int(info.depth), nil
+}
+
+func (display *Display) screen(requested int) (C.int, error) {
+	if requested == DefaultScreen {
+		return C.XDefaultScreen(display.xDisplay), nil
+	}
+	count := int(C.XScreenCount(display.xDisplay))
+	if requested < 0 || requested >= count {
+		return 0, fmt.Errorf("X screen %d does not exist, display has %d screens", requested, count)
+	}
+	return C.int(requested), nil
+}
+
 func (display *Display) CreatePixmapSurface(config Config, attribList []Attrib, width, height int) (*Surface, error) {
-	rootWindow := C.XDefaultRootWindow(display.xDisplay)
+	return display.CreatePixmapSurfaceWithOptions(config, attribList, width, height, PixmapOptions{Screen: DefaultScreen})
+}
+
+/*
+ * CreatePixmapSurfaceWithOptions creates a pixmap whose depth matches the
+ * config's visual, so configs with 16 or 24-bit visuals work as well as
+ * 32-bit ones. With options.Shm the pixmap's memory is a shared memory
+ * segment other processes can map, see ShmPixmapInfo.
+ */
+func (display *Display) CreatePixmapSurfaceWithOptions(config Config, attribList []Attrib, width, height int, options PixmapOptions) (*Surface, error) {
+	if display.xDisplay == nil {
+		return nil, errors.New("pixmap surfaces require a display opened on an X server")
+	}
+	colorspaceErr := display.checkColorspace(attribList)
+	if colorspaceErr != nil {
+		return nil, colorspaceErr
+	}
+
+	screen, screenErr := display.screen(options.Screen)
+	if screenErr != nil {
+		return nil, screenErr
+	}
+	visual, depth, visualErr := display.pixmapVisual(config, screen)
+	if visualErr != nil {
+		return nil, visualErr
+	}
+
+	rootWindow := C.XRootWindow(display.xDisplay, screen)
 //	fmt.Printf("got root window == %d\n", rootWindow)
 
 	if width < 0 {
@@ -195,7 +320,17 @@ func (display *Display) CreatePixmapSurface(config Config, attribList []Attrib,
 	if height < 0 {
 		height = -height
 	}
-	pixmap := C.XCreatePixmap(display.xDisplay, C.Drawable(rootWindow), C.uint(width), C.uint(height), pixelBitCount)
+	var pixmap C.Pixmap
+	var segment *shmSegment
+	if options.Shm {
+		var shmErr error
+		pixmap, segment, shmErr = display.createShmPixmap(C.Drawable(rootWindow), visual, depth, width, height)
+		if shmErr != nil {
+			return nil, shmErr
+		}
+	} else {
+		pixmap = C.XCreatePixmap(display.xDisplay, C.Drawable(rootWindow), C.uint(width), C.uint(height), C.uint(depth))
+	}
 //	fmt.Printf("created pixmap == %d\n", pixmap)
 
 /*
@@ -211,15 +346,28 @@ func (display *Display) CreatePixmapSurface(config Config, attribList []Attrib,
 	}
 	eglSurface := C.eglCreatePixmapSurface(display.eglDisplay, C.EGLConfig(config), C.EGLNativePixmapType(pixmap), eglAttribs)
 	if eglSurface == noSurface {
+		eglErr := getError()
 		C.XFreePixmap(display.xDisplay, pixmap)
-		return nil, getError()
+		if segment != nil {
+			segment.destroy()
+		}
+		return nil, eglErr
 	}
 
 	surface := new(Surface)
 	//runtime.SetFinalizer(surface, destroySurface)
 	surface.Display = display
 	surface.eglSurface = eglSurface
+	surface.config = config
 	surface.xPixmap = pixmap
+	surface.ownsPixmap = true
+	surface.shmPixmap = segment
+	surface.width = width
+	surface.height = height
+	surface.screen = screen
+	surface.visual = visual
+	surface.depth = depth
+	surface.colorspaceRequested = requestsColorspace(attribList)
 	return surface, nil
 }
 
@@ -231,39 +379,213 @@ func (surface *Surface) Destroy() error {
 		result = getError()
 	}
 
-	if surface.xPixmap != 0 {
+	if surface.xPixmap != 0 && surface.ownsPixmap {
 		C.XFreePixmap(surface.Display.xDisplay, surface.xPixmap)
 	}
+	if surface.shmPixmap != nil {
+		surface.shmPixmap.destroy()
+		surface.shmPixmap = nil
+	}
+
+	if surface.readImage != nil {
+		C.destroyXImage(surface.readImage)
+		surface.readImage = nil
+	}
+	surface.DisableShm()
 
 	return result
 }
 
-func (surface *Surface) CopyBuffers() (*image.NRGBA, error) {
+/*
+ * CopyBuffers reads the surface's color buffer back into an *image.RGBA if
+ * the surface's alpha is premultiplied or it has no alpha, or an
+ * *image.NRGBA otherwise. Visuals with more than 8 bits per component, like
+ * 30-bit ones, give an *image.RGBA64 or *image.NRGBA64 instead. Pixels are
+ * converted from the X visual's layout, whatever its masks, depth and byte
+ * order. Pbuffers are read with ReadPixels when possible, which also
+ * handles floating point configs.
+ */
+func (surface *Surface) CopyBuffers() (image.Image, error) {
+	if surface.timer != nil {
+		defer surface.timer.record(time.Now(), surface.swapInterval)
+	}
+
+	if surface.usesGLReadback() {
+		return surface.ReadPixels()
+	}
+
+	bounds, boundsErr := surface.bounds()
+	if boundsErr != nil {
+		return nil, boundsErr
+	}
+
+	xImage, format, readErr := surface.readXImage(bounds)
+	if readErr != nil {
+		return nil, readErr
+	}
+	premultiplied, alphaErr := surface.alphaPremultiplied()
+	if alphaErr != nil {
+		return nil, alphaErr
+	}
+
+	var goImage draw.Image
+	premultipliedData := premultiplied || !format.hasAlpha()
+	switch {
+		case format.isDeep() && premultipliedData:
+			goImage = image.NewRGBA64(bounds)
+		case format.isDeep():
+			goImage = image.NewNRGBA64(bounds)
+		case premultipliedData:
+			goImage = image.NewRGBA(bounds)
+		default:
+			goImage = image.NewNRGBA(bounds)
+	}
+	surface.decodeXImage(xImage, format, premultiplied, goImage, bounds)
+
+	return goImage, nil
+}
+
+/*
+ * CopyBuffersInto reads the part of the color buffer inside rect into the
+ * same pixels of dst, clipped to both the surface and dst. Unlike
+ * CopyBuffers it allocates nothing after the first call with a given size
+ * when dst is an *image.RGBA or *image.NRGBA, or an *image.RGBA64 or
+ * *image.NRGBA64 whose alpha matches the surface's, so it suits capture
+ * loops that reuse dst. Other images go through dst.Set, which allocates
+ * for every pixel.
+ */
+func (surface *Surface) CopyBuffersInto(dst draw.Image, rect image.Rectangle) error {
+	if surface.timer != nil {
+		defer surface.timer.record(time.Now(), surface.swapInterval)
+	}
+
+	bounds, boundsErr := surface.bounds()
+	if boundsErr != nil {
+		return boundsErr
+	}
+	rect = rect.Intersect(bounds).Intersect(dst.Bounds())
+	if rect.Empty() {
+		return nil
+	}
+	if surface.usesGLReadback() {
+		return surface.readPixelsInto(dst, rect)
+	}
+
+	xImage, format, readErr := surface.readXImage(rect)
+	if readErr != nil {
+		return readErr
+	}
+	premultiplied, alphaErr := surface.alphaPremultiplied()
+	if alphaErr != nil {
+		return alphaErr
+	}
+
+	surface.decodeXImage(xImage, format, premultiplied, dst, rect)
+	return nil
+}
+
+/*
+ * usesGLReadback reports whether a pbuffer should be read with glReadPixels
+ * rather than copied to an X pixmap, which is when it is current or there
+ * is no X server to copy through.
+ */
+func (surface *Surface) usesGLReadback() bool {
+	if surface.xPixmap != 0 || surface.xWindow != 0 {
+		return false
+	}
+	return surface.Display.xDisplay == nil || surface.isCurrentRead()
+}
+
+/*
+ * bounds returns the surface's size. Pixmaps can't change size so theirs is
+ * remembered, other surfaces are queried every time.
+ */
+func (surface *Surface) bounds() (image.Rectangle, error) {
+	if surface.xPixmap != 0 && surface.width > 0 {
+		return image.Rect(0, 0, surface.width, surface.height), nil
+	}
+
 	width, widthErr := surface.Query(Width)
 	if widthErr != nil {
-		return nil, widthErr
+		return image.Rectangle{}, widthErr
 	}
 
 	height, heightErr := surface.Query(Height)
 	if heightErr != nil {
-		return nil, heightErr
+		return image.Rectangle{}, heightErr
 	}
 
+	return image.Rect(0, 0, int(width), int(height)), nil
+}
+
+/*
+ * readXImage fetches rect from the X drawable behind the surface into the
+ * top-left corner of an XImage. The XImage is kept on the surface and
+ * refilled with XGetSubImage while it is large enough.
+ */
+func (surface *Surface) readXImage(rect image.Rectangle) (*C.XImage, pixelFormat, error) {
 	display := surface.Display
 	xDisplay := display.xDisplay
-	pixmap := surface.xPixmap
-	if pixmap == 0 {
-		pixmap = C.XCreatePixmap(xDisplay, C.Drawable(C.XDefaultRootWindow(xDisplay)), C.uint(width), C.uint(height), pixelBitCount)
-//		pixmap = C.XCreatePixmap(xDisplay, C.Drawable(C.XDefaultScreen(xDisplay)), C.uint(width), C.uint(height), pixelBitCount)
-		fmt.Printf("surface is not a pixmap surface, created temporary pixmap == %d\n", pixmap)
+	visual := surface.visual
+	drawable := C.Drawable(surface.xPixmap)
+	if drawable == 0 && surface.xWindow != 0 {
+		// window contents can be read back directly
+		drawable = C.Drawable(surface.xWindow)
+	}
+	if drawable == 0 {
+		bounds, boundsErr := surface.bounds()
+		if boundsErr != nil {
+			return nil, pixelFormat{}, boundsErr
+		}
+		width, height := bounds.Dx(), bounds.Dy()
+		screen := C.XDefaultScreen(xDisplay)
+		pixmapVisual, depth, visualErr := display.pixmapVisual(surface.config, screen)
+		if visualErr != nil {
+			return nil, pixelFormat{}, visualErr
+		}
+		// the pixmap's layout, and so its masks, come from this visual
+		visual = pixmapVisual
+		pixmap := C.XCreatePixmap(xDisplay, C.Drawable(C.XRootWindow(xDisplay, screen)), C.uint(width), C.uint(height), C.uint(depth))
 		defer C.XFreePixmap(xDisplay, pixmap)
 
-		C.eglCopyBuffers(display.eglDisplay, surface.eglSurface, C.EGLNativePixmapType(pixmap))
+		success := C.eglCopyBuffers(display.eglDisplay, surface.eglSurface, C.EGLNativePixmapType(pixmap))
+		if success == C.EGL_FALSE {
+			return nil, pixelFormat{}, getError()
+		}
+		drawable = C.Drawable(pixmap)
 	}
 
-	xImage := C.XGetImage(xDisplay, C.Drawable(pixmap), 0, 0, C.uint(width), C.uint(height), pixelMask, C.ZPixmap)
-	if xImage == nil {
-		return nil, errors.New("XGetImage returned nil")
+	if surface.shm != nil && !surface.shm.viewHeld {
+		xImage, shmErr := surface.readShmXImage(drawable, rect)
+		if shmErr == nil {
+			format, formatErr := xImageFormat(xImage, visual)
+			if formatErr != nil {
+				return nil, pixelFormat{}, formatErr
+			}
+			return xImage, format, nil
+		}
+		// fall back to XGetImage from now on
+		surface.DisableShm()
+	}
+
+	x, y := C.int(rect.Min.X), C.int(rect.Min.Y)
+	width, height := C.uint(rect.Dx()), C.uint(rect.Dy())
+	xImage := surface.readImage
+	if xImage != nil && int(xImage.width) >= rect.Dx() && int(xImage.height) >= rect.Dy() {
+		result := C.XGetSubImage(xDisplay, drawable, x, y, width, height, pixelMask, C.ZPixmap, xImage, 0, 0)
+		if result == nil {
+			return nil, pixelFormat{}, errors.New("XGetSubImage returned nil")
+		}
+	} else {
+		if xImage != nil {
+			C.destroyXImage(xImage)
+			surface.readImage = nil
+		}
+		xImage = C.XGetImage(xDisplay, drawable, x, y, width, height, pixelMask, C.ZPixmap)
+		if xImage == nil {
+			return nil, pixelFormat{}, errors.New("XGetImage returned nil")
+		}
+		surface.readImage = xImage
 	}
 
 /*
@@ -285,17 +607,132 @@ func (surface *Surface) CopyBuffers() (*image.NRGBA, error) {
 	fmt.Printf("\tblue_mask == 0x%X\n", xImage.blue_mask)
 */
 
-	bounds := image.Rect(0, 0, int(xImage.width), int(xImage.height))
-	goImage := image.NewNRGBA(bounds)
-	rowBytes := int(xImage.width) * pixelByteCount
-	xLength := xImage.height * xImage.bytes_per_line
-	xSlice := C.GoBytes(unsafe.Pointer(xImage.data), xLength)
-	for y := 0; y < int(xImage.height); y++ {
-		xOffset := y * int(xImage.bytes_per_line)
-		goOffset := y * goImage.Stride
+	format, formatErr := xImageFormat(xImage, visual)
+	if formatErr != nil {
+		return nil, pixelFormat{}, formatErr
+	}
+	return xImage, format, nil
+}
+
+// How decodeXImage writes rows into typed destinations.
+const (
+	decodeAsIs = iota
+	decodePremultiplied // 8-bit, premultiplying unpremultiplied pixels
+	decodeUnpremultiplied // 8-bit, the reverse
+	decodeDeep // 16-bit
+)
+
+/*
+ * decodeXImage converts the top-left rect.Dx() by rect.Dy() pixels of
+ * xImage into rect of dst, reading the XImage's memory in place.
+ */
+func (surface *Surface) decodeXImage(xImage *C.XImage, format pixelFormat, premultiplied bool, dst draw.Image, rect image.Rectangle) {
+	width := rect.Dx()
+	bytesPerLine := int(xImage.bytes_per_line)
+	xLength := int(xImage.height) * bytesPerLine
+	xSlice := unsafe.Slice((*byte)(unsafe.Pointer(xImage.data)), xLength)
+
+	premultipliedData := premultiplied || !format.hasAlpha()
+	opaqueData := !format.hasAlpha()
+	// rows are decoded straight into the destination's pixels
+	var pix []uint8
+	var offset, stride int
+	conversion := -1
+	switch dstImage := dst.(type) {
+		case *image.RGBA:
+			pix, offset, stride = dstImage.Pix, dstImage.PixOffset(rect.Min.X, rect.Min.Y), dstImage.Stride
+			conversion = decodeAsIs
+			if !premultipliedData {
+				conversion = decodePremultiplied
+			}
+		case *image.NRGBA:
+			pix, offset, stride = dstImage.Pix, dstImage.PixOffset(rect.Min.X, rect.Min.Y), dstImage.Stride
+			conversion = decodeAsIs
+			if premultipliedData && !opaqueData {
+				conversion = decodeUnpremultiplied
+			}
+		case *image.RGBA64:
+			if premultipliedData {
+				pix, offset, stride = dstImage.Pix, dstImage.PixOffset(rect.Min.X, rect.Min.Y), dstImage.Stride
+				conversion = decodeDeep
+			}
+		case *image.NRGBA64:
+			if !premultipliedData || opaqueData {
+				pix, offset, stride = dstImage.Pix, dstImage.PixOffset(rect.Min.X, rect.Min.Y), dstImage.Stride
+				conversion = decodeDeep
+			}
+	}
+	if conversion >= 0 {
+		for y := 0; y < rect.Dy(); y++ {
+			row := pix[offset + y * stride:]
+			src := xSlice[y * bytesPerLine:]
+			switch conversion {
+				case decodeAsIs:
+					format.decodeRow(row, src, width)
+				case decodePremultiplied:
+					format.decodeRow(row, src, width)
+					premultiplyRow(row, width)
+				case decodeUnpremultiplied:
+					format.decodeRow(row, src, width)
+					unpremultiplyRow(row, width)
+				case decodeDeep:
+					format.decodeRow64(row, src, width)
+			}
+		}
+		return
+	}
+
+	if len(surface.rowBuffer) < width * 8 {
+		surface.rowBuffer = make([]byte, width * 8)
+	}
+	row := surface.rowBuffer
+	for y := 0; y < rect.Dy(); y++ {
+		format.decodeRow64(row, xSlice[y * bytesPerLine:], width)
+		for x := 0; x < width; x++ {
+			p := row[x * 8:x * 8 + 8]
+			r := uint16(p[0]) << 8 | uint16(p[1])
+			g := uint16(p[2]) << 8 | uint16(p[3])
+			b := uint16(p[4]) << 8 | uint16(p[5])
+			a := uint16(p[6]) << 8 | uint16(p[7])
+			var c color.Color
+			if premultipliedData {
+				c = color.RGBA64{r, g, b, a}
+			} else {
+				c = color.NRGBA64{r, g, b, a}
+			}
+			dst.Set(rect.Min.X + x, rect.Min.Y + y, c)
+		}
+	}
+}
 
-		copy(goImage.Pix[goOffset:goOffset + rowBytes], xSlice[xOffset:xOffset + rowBytes])
+/*
+ * xImageFormat describes xImage's pixels. XGetImage on a pixmap returns an
+ * XImage with no visual and so no color masks, so they are taken from
+ * visual, the drawable's visual, when it is known.
+ */
+func xImageFormat(xImage *C.XImage, visual *C.Visual) (pixelFormat, error) {
+	if xImage.format != C.ZPixmap {
+		return pixelFormat{}, errors.New("XImage is not in ZPixmap format")
+	}
+	red, green, blue := xImage.red_mask, xImage.green_mask, xImage.blue_mask
+	if visual != nil {
+		red, green, blue = visual.red_mask, visual.green_mask, visual.blue_mask
 	}
+	return newPixelFormat(
+		int(xImage.bits_per_pixel),
+		int(xImage.depth),
+		xImage.byte_order == C.MSBFirst,
+		uint32(red),
+		uint32(green),
+		uint32(blue))
+}
 
-	return goImage, nil
+// alphaPremultiplied reports whether the surface's EGL_ALPHA_FORMAT is
+// EGL_ALPHA_FORMAT_PRE.
+func (surface *Surface) alphaPremultiplied() (bool, error) {
+	alphaFormat, queryErr := surface.Query(VgAlphaFormat)
+	if queryErr != nil {
+		return false, queryErr
+	}
+	return alphaFormat == VgAlphaFormatPre, nil
 }
@@ -0,0 +1,80 @@
+package egl
+
+import (
+	"image"
+	"image/draw"
+	"testing"
+)
+
+const benchmarkSize = 512
+
+// newBenchmarkPixmap creates a pixmap surface for readback benchmarks.
+func newBenchmarkPixmap(b *testing.B) (*Display, *Surface) {
+	display := openTestXDisplay(b)
+	config := chooseTestConfig(b, display, PixmapBit)
+	surface, surfaceErr := display.CreatePixmapSurface(config, nil, benchmarkSize, benchmarkSize)
+	if surfaceErr != nil {
+		display.Close()
+		b.Skip(surfaceErr)
+	}
+	return display, surface
+}
+
+func BenchmarkCopyBuffers(b *testing.B) {
+	display, surface := newBenchmarkPixmap(b)
+	defer display.Close()
+	defer surface.Destroy()
+
+	b.ReportAllocs()
+	b.SetBytes(benchmarkSize * benchmarkSize * 4)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, copyErr := surface.CopyBuffers()
+		if copyErr != nil {
+			b.Fatal(copyErr)
+		}
+	}
+}
+
+func benchmarkCopyBuffersInto(b *testing.B, dst draw.Image, rect image.Rectangle) {
+	display, surface := newBenchmarkPixmap(b)
+	defer display.Close()
+	defer surface.Destroy()
+
+	// the first call sizes the reused XImage
+	copyErr := surface.CopyBuffersInto(dst, rect)
+	if copyErr != nil {
+		b.Fatal(copyErr)
+	}
+
+	b.ReportAllocs()
+	b.SetBytes(int64(rect.Dx() * rect.Dy() * 4))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		copyErr = surface.CopyBuffersInto(dst, rect)
+		if copyErr != nil {
+			b.Fatal(copyErr)
+		}
+	}
+}
+
+func BenchmarkCopyBuffersIntoRGBA(b *testing.B) {
+	bounds := image.Rect(0, 0, benchmarkSize, benchmarkSize)
+	benchmarkCopyBuffersInto(b, image.NewRGBA(bounds), bounds)
+}
+
+func BenchmarkCopyBuffersIntoNRGBA(b *testing.B) {
+	bounds := image.Rect(0, 0, benchmarkSize, benchmarkSize)
+	benchmarkCopyBuffersInto(b, image.NewNRGBA(bounds), bounds)
+}
+
+func BenchmarkCopyBuffersIntoSubRect(b *testing.B) {
+	bounds := image.Rect(0, 0, benchmarkSize, benchmarkSize)
+	benchmarkCopyBuffersInto(b, image.NewRGBA(bounds), image.Rect(64, 64, 192, 192))
+}
+
+// BenchmarkCopyBuffersIntoGray measures the dst.Set fallback.
+func BenchmarkCopyBuffersIntoGray(b *testing.B) {
+	bounds := image.Rect(0, 0, benchmarkSize, benchmarkSize)
+	benchmarkCopyBuffersInto(b, image.NewGray(bounds), bounds)
+}